@@ -1,36 +1,251 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/lib/pq"
+	"github.com/markusryoti/survey-ddd/config"
+	"github.com/markusryoti/survey-ddd/internal/adapters/metrics"
 	"github.com/markusryoti/survey-ddd/internal/adapters/postgres"
 	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/markusryoti/survey-ddd/internal/adapters/tracing"
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/application/command"
 	"github.com/markusryoti/survey-ddd/internal/application/query"
+	"github.com/markusryoti/survey-ddd/internal/application/service"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/markusryoti/survey-ddd/internal/lifecycle"
+	"github.com/markusryoti/survey-ddd/internal/logging"
+	"github.com/markusryoti/survey-ddd/internal/ports"
+)
+
+const (
+	// sagaResumeInterval is how often unfinished submission sagas are
+	// processed again, e.g. after the survey they count in was busy.
+	sagaResumeInterval = time.Minute
 )
 
 func main() {
-	db, err := sql.Open("postgres", "postgres://survey:secret@db:5432/surveydb?sslmode=disable")
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("invalid configuration:\n%v", err)
 	}
 
-	transactional := postgres.NewPostgresTransactionalProvider(db)
+	logger := logging.New(os.Stdout, cfg.Log.Format, cfg.Log.SlogLevel())
+	slog.SetDefault(logger)
+
+	db, err := sql.Open("postgres", cfg.Database.DSN)
+	if err != nil {
+		fatal(logger, "failed to start", err)
+	}
+
+	db.SetMaxOpenConns(cfg.Database.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.Database.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.Database.ConnMaxLifetime)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(context.Background(), db, os.Args[2:]); err != nil {
+			fatal(logger, "migrate failed", err)
+		}
+		return
+	}
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		fatal(logger, "failed to start", err)
+	}
+
+	if cfg.Database.MigrateOnStart {
+		if err := migrateOnStart(context.Background(), logger, migrator); err != nil {
+			fatal(logger, "failed to migrate database", err)
+		}
+	}
 
-	surveyCommandHandler := command.NewCommandHandler(transactional)
-	queryHandler := query.NewQueryHandler(transactional)
+	exporter, err := tracing.NewExporter(cfg.Tracing.Exporter, os.Stdout)
+	if err != nil {
+		fatal(logger, "failed to start", err)
+	}
+
+	appTracing := tracing.New(cfg.Tracing.ServiceName, exporter)
+	appTracing.Install()
+
+	registry := core.NewEventRegistry()
+	surveys.RegisterEvents(registry)
+
+	appMetrics := metrics.New()
+	appMetrics.MustRegister(metrics.OutboxCollector(postgres.OutboxCounts(db)))
+
+	events := core.NewEventBus(logger)
+	transactional := core.NewPublishingTransactionProvider(
+		appMetrics.TransactionProvider(
+			appTracing.TransactionProvider(postgres.NewPostgresTransactionalProvider(db, registry, logger)),
+		),
+		events,
+	)
+
+	policy := auth.NewRolePolicy()
+
+	commands := core.NewCommandBus(
+		core.ObserverMiddleware(appTracing),
+		core.ObserverMiddleware(appMetrics),
+		core.LoggingMiddleware(logger),
+		auth.AuthenticationMiddleware(),
+		core.ValidationMiddleware(),
+		core.RetryMiddleware(core.DefaultRetryPolicy()),
+	)
+	command.NewCommandHandler(transactional, policy, logger).Register(commands)
+
+	queryHandler := query.NewQueryHandler(
+		transactional,
+		policy,
+		postgres.NewPostgresInvitationReader(db),
+		postgres.NewPostgresResponseReader(db),
+		postgres.NewPostgresEventStore(db, registry),
+		logger,
+		appTracing,
+	)
+
+	// Sagas left unfinished while the saga was enabled are still resumed
+	// after it has been disabled.
+	submissionSaga := service.NewSubmissionSaga(transactional, postgres.NewPostgresSubmissionSagaReader(db))
+
+	var serviceOpts []service.SurveyServiceOption
+	if cfg.Features.SubmissionSaga {
+		submissionSaga.Register(events)
+		serviceOpts = append(serviceOpts, service.WithSubmissionSaga())
+	}
+
+	surveyService := service.NewSurveyService(transactional, policy, serviceOpts...)
+
+	idempotencyStore := postgres.NewPostgresIdempotencyStore(db)
 
 	surveyHandler := rest.SurveyHandler{
-		CommandHandler: surveyCommandHandler,
-		QueryHandler:   queryHandler,
+		Commands:     commands,
+		QueryHandler: queryHandler,
+		Responses:    surveyService,
+		GatewayKeys:  cfg.Auth.GatewayKeys,
+		Logger:       logger,
+	}
+
+	if cfg.Features.Idempotency {
+		surveyHandler.Idempotency = rest.NewIdempotency(transactional, idempotencyStore, cfg.Idempotency.KeyRetention, logger)
+	}
+
+	healthHandler := rest.HealthHandler{
+		Timeout: cfg.Health.CheckTimeout,
+		Checks: []rest.HealthCheck{
+			{Name: "database", Check: db.PingContext},
+			{Name: "migrations", Check: migrator.CheckApplied},
+		},
 	}
 
+	// Outbox messages are only relayed when a broker is configured, so the
+	// backlog is expected to grow without one.
+	if cfg.RabbitMQ.URL != "" {
+		healthHandler.Checks = append(healthHandler.Checks,
+			rest.HealthCheck{
+				Name:  "broker",
+				Check: rest.DialCheck(cfg.RabbitMQ.Address()),
+			},
+			rest.HealthCheck{
+				Name:  "outbox",
+				Check: postgres.OutboxBacklogCheck(db, cfg.Health.OutboxBacklogThreshold),
+			},
+		)
+	}
+
+	// Health and metrics endpoints are outside the survey routes so orchestrators don't
+	// need credentials to reach them.
 	r := chi.NewRouter()
-	surveyHandler.RegisterRoutes(r)
+	r.Use(appTracing.Middleware)
+	r.Use(appMetrics.Middleware)
+	r.Handle("/metrics", appMetrics.Handler())
+	healthHandler.RegisterRoutes(r)
+	r.Group(surveyHandler.RegisterRoutes)
+
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Components are stopped in reverse: the server drains in-flight requests
+	// first, then the workers stop, the database is closed and the remaining
+	// spans are exported last.
+	app := lifecycle.NewManager(cfg.HTTP.ShutdownTimeout, logger)
+	app.Add(
+		lifecycle.Component{Name: "tracing", Stop: appTracing.Shutdown},
+		lifecycle.Closer("database", db),
+		lifecycle.Worker(app, "submission saga resumer", func(ctx context.Context) error {
+			resumeSubmissionSagas(ctx, logger, submissionSaga, sagaResumeInterval)
+			return nil
+		}),
+		lifecycle.Worker(app, "idempotency key purger", func(ctx context.Context) error {
+			purgeIdempotencyKeys(ctx, logger, idempotencyStore, cfg.Idempotency)
+			return nil
+		}),
+		lifecycle.Server(app, server),
+	)
 
-	http.ListenAndServe(":8080", r)
+	if err := app.Run(ctx); err != nil {
+		fatal(logger, "stopped with errors", err)
+	}
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, slog.String("error", err.Error()))
+	os.Exit(1)
+}
+
+// resumeSubmissionSagas processes the sagas left unfinished by a restart,
+// and then the ones whose processing failed every interval.
+func resumeSubmissionSagas(ctx context.Context, logger *slog.Logger, saga *service.SubmissionSaga, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := saga.Resume(ctx); err != nil && ctx.Err() == nil {
+			logger.ErrorContext(ctx, "failed to resume submission sagas", slog.String("error", err.Error()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeIdempotencyKeys deletes the keys older than the retention every
+// purge interval.
+func purgeIdempotencyKeys(ctx context.Context, logger *slog.Logger, store ports.IdempotencyStore, cfg config.Idempotency) {
+	ticker := time.NewTicker(cfg.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := store.DeleteExpired(ctx, time.Now().Add(-cfg.KeyRetention)); err != nil {
+				logger.ErrorContext(ctx, "failed to purge idempotency keys", slog.String("error", err.Error()))
+			}
+		}
+	}
 }
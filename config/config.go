@@ -1 +1,411 @@
 package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the configuration of the API. It's read from an optional YAML
+// file and then from environment variables, which take precedence.
+type Config struct {
+	Database    Database    `yaml:"database"`
+	HTTP        HTTP        `yaml:"http"`
+	RabbitMQ    RabbitMQ    `yaml:"rabbitmq"`
+	Auth        Auth        `yaml:"auth"`
+	Log         Log         `yaml:"log"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Health      Health      `yaml:"health"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Features    Features    `yaml:"features"`
+}
+
+type Database struct {
+	DSN             string        `yaml:"dsn"`
+	MaxOpenConns    int           `yaml:"maxOpenConns"`
+	MaxIdleConns    int           `yaml:"maxIdleConns"`
+	ConnMaxLifetime time.Duration `yaml:"connMaxLifetime"`
+	// MigrateOnStart applies pending migrations before the server starts.
+	MigrateOnStart bool `yaml:"migrateOnStart"`
+}
+
+type HTTP struct {
+	Addr              string        `yaml:"addr"`
+	ReadHeaderTimeout time.Duration `yaml:"readHeaderTimeout"`
+	ReadTimeout       time.Duration `yaml:"readTimeout"`
+	WriteTimeout      time.Duration `yaml:"writeTimeout"`
+	IdleTimeout       time.Duration `yaml:"idleTimeout"`
+	// ShutdownTimeout bounds how long in-flight requests and background
+	// workers are waited for on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout"`
+}
+
+type RabbitMQ struct {
+	// URL is optional, messaging is disabled without it.
+	URL string `yaml:"url"`
+}
+
+// Address returns the host and port of the broker.
+func (r RabbitMQ) Address() string {
+	u, err := url.Parse(r.URL)
+	if err != nil {
+		return ""
+	}
+
+	if u.Port() != "" {
+		return u.Host
+	}
+
+	port := "5672"
+	if u.Scheme == "amqps" {
+		port = "5671"
+	}
+
+	return net.JoinHostPort(u.Hostname(), port)
+}
+
+type Auth struct {
+	// GatewayKeys are the keys the authenticating gateway may present. When
+	// set, identities are only accepted from requests carrying one of them.
+	GatewayKeys []string `yaml:"gatewayKeys"`
+}
+
+type Log struct {
+	Level string `yaml:"level"`
+	// Format is json or text.
+	Format string `yaml:"format"`
+}
+
+// SlogLevel returns the level as a slog level. The level must be valid.
+func (l Log) SlogLevel() slog.Level {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(l.Level))
+	return level
+}
+
+type Tracing struct {
+	// Exporter is none or stdout. Spans are still propagated with none, so
+	// trace context from upstream services reaches the outbox.
+	Exporter    string `yaml:"exporter"`
+	ServiceName string `yaml:"serviceName"`
+}
+
+type Health struct {
+	// CheckTimeout bounds each readiness check.
+	CheckTimeout time.Duration `yaml:"checkTimeout"`
+	// OutboxBacklogThreshold is the number of pending outbox messages above
+	// which the service reports itself as not ready. Checked only when a broker
+	// is configured.
+	OutboxBacklogThreshold int `yaml:"outboxBacklogThreshold"`
+}
+
+type Idempotency struct {
+	// KeyRetention is how long the responses of idempotent requests are kept
+	// for replaying.
+	KeyRetention time.Duration `yaml:"keyRetention"`
+	// PurgeInterval is how often keys older than the retention are deleted.
+	PurgeInterval time.Duration `yaml:"purgeInterval"`
+}
+
+type Features struct {
+	// Idempotency enables replaying responses of retried requests sent with
+	// an Idempotency-Key header.
+	Idempotency bool `yaml:"idempotency"`
+	// SubmissionSaga counts submitted responses in their survey
+	// asynchronously, instead of in the submitting transaction.
+	SubmissionSaga bool `yaml:"submissionSaga"`
+}
+
+// Default returns the configuration used for settings that aren't given.
+func Default() Config {
+	return Config{
+		Database: Database{
+			DSN:             "postgres://survey:secret@db:5432/surveydb?sslmode=disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 30 * time.Minute,
+			MigrateOnStart:  true,
+		},
+		HTTP: HTTP{
+			Addr:              ":8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      15 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Log: Log{
+			Level:  "info",
+			Format: "json",
+		},
+		Tracing: Tracing{
+			Exporter:    "none",
+			ServiceName: "survey-api",
+		},
+		Health: Health{
+			CheckTimeout:           2 * time.Second,
+			OutboxBacklogThreshold: 1000,
+		},
+		Idempotency: Idempotency{
+			KeyRetention:  24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Features: Features{
+			Idempotency: true,
+		},
+	}
+}
+
+// Load reads the configuration from the file at path, if given, and the
+// environment, and validates it.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		err := cfg.loadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	err := cfg.loadEnv()
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = cfg.Validate()
+	if err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	switch ext := filepath.Ext(path); ext {
+	case ".yaml", ".yml":
+	default:
+		return fmt.Errorf("unsupported config file format %q, use YAML", ext)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(c)
+	if err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// loadEnv overrides the settings for which an environment variable is set.
+func (c *Config) loadEnv() error {
+	env := envReader{lookup: os.LookupEnv}
+
+	env.string("DATABASE_URL", &c.Database.DSN)
+	env.int("DATABASE_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	env.int("DATABASE_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	env.duration("DATABASE_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	env.bool("MIGRATE_ON_START", &c.Database.MigrateOnStart)
+
+	env.string("HTTP_ADDR", &c.HTTP.Addr)
+	env.duration("HTTP_READ_HEADER_TIMEOUT", &c.HTTP.ReadHeaderTimeout)
+	env.duration("HTTP_READ_TIMEOUT", &c.HTTP.ReadTimeout)
+	env.duration("HTTP_WRITE_TIMEOUT", &c.HTTP.WriteTimeout)
+	env.duration("HTTP_IDLE_TIMEOUT", &c.HTTP.IdleTimeout)
+	env.duration("HTTP_SHUTDOWN_TIMEOUT", &c.HTTP.ShutdownTimeout)
+
+	env.string("RABBITMQ_URL", &c.RabbitMQ.URL)
+
+	env.list("AUTH_GATEWAY_KEYS", &c.Auth.GatewayKeys)
+
+	env.string("LOG_LEVEL", &c.Log.Level)
+	env.string("LOG_FORMAT", &c.Log.Format)
+
+	env.string("TRACING_EXPORTER", &c.Tracing.Exporter)
+	env.string("TRACING_SERVICE_NAME", &c.Tracing.ServiceName)
+
+	env.duration("HEALTH_CHECK_TIMEOUT", &c.Health.CheckTimeout)
+	env.int("HEALTH_OUTBOX_BACKLOG_THRESHOLD", &c.Health.OutboxBacklogThreshold)
+
+	env.duration("IDEMPOTENCY_KEY_RETENTION", &c.Idempotency.KeyRetention)
+	env.duration("IDEMPOTENCY_PURGE_INTERVAL", &c.Idempotency.PurgeInterval)
+
+	env.bool("FEATURE_IDEMPOTENCY", &c.Features.Idempotency)
+	env.bool("FEATURE_SUBMISSION_SAGA", &c.Features.SubmissionSaga)
+
+	return errors.Join(env.errs...)
+}
+
+// Validate reports every invalid setting at once.
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.Database.DSN == "" {
+		errs = append(errs, errors.New("database dsn is required (DATABASE_URL)"))
+	}
+	if c.Database.MaxOpenConns < 1 {
+		errs = append(errs, fmt.Errorf("database maxOpenConns must be at least 1, got %d", c.Database.MaxOpenConns))
+	}
+	if c.Database.MaxIdleConns < 0 || c.Database.MaxIdleConns > c.Database.MaxOpenConns {
+		errs = append(errs, fmt.Errorf("database maxIdleConns must be between 0 and maxOpenConns, got %d", c.Database.MaxIdleConns))
+	}
+	if c.Database.ConnMaxLifetime < 0 {
+		errs = append(errs, errors.New("database connMaxLifetime can't be negative"))
+	}
+
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http addr is required (HTTP_ADDR)"))
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"readHeaderTimeout", c.HTTP.ReadHeaderTimeout},
+		{"readTimeout", c.HTTP.ReadTimeout},
+		{"writeTimeout", c.HTTP.WriteTimeout},
+		{"idleTimeout", c.HTTP.IdleTimeout},
+		{"shutdownTimeout", c.HTTP.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("http %s must be positive, got %s", timeout.name, timeout.value))
+		}
+	}
+
+	if c.RabbitMQ.URL != "" {
+		u, err := url.Parse(c.RabbitMQ.URL)
+		if err != nil || (u.Scheme != "amqp" && u.Scheme != "amqps") {
+			errs = append(errs, errors.New("rabbitmq url must be an amqp:// or amqps:// url"))
+		}
+	}
+
+	for _, key := range c.Auth.GatewayKeys {
+		if key == "" {
+			errs = append(errs, errors.New("auth gatewayKeys can't contain empty keys"))
+			break
+		}
+	}
+
+	if c.Health.CheckTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("health checkTimeout must be positive, got %s", c.Health.CheckTimeout))
+	}
+	if c.Health.OutboxBacklogThreshold < 0 {
+		errs = append(errs, fmt.Errorf("health outboxBacklogThreshold can't be negative, got %d", c.Health.OutboxBacklogThreshold))
+	}
+
+	if c.Idempotency.KeyRetention <= 0 {
+		errs = append(errs, fmt.Errorf("idempotency keyRetention must be positive, got %s", c.Idempotency.KeyRetention))
+	}
+	if c.Idempotency.PurgeInterval <= 0 {
+		errs = append(errs, fmt.Errorf("idempotency purgeInterval must be positive, got %s", c.Idempotency.PurgeInterval))
+	}
+
+	if c.Log.Format != "json" && c.Log.Format != "text" {
+		errs = append(errs, fmt.Errorf("log format must be json or text, got %q", c.Log.Format))
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log level must be one of debug, info, warn or error, got %q", c.Log.Level))
+	}
+
+	if c.Tracing.Exporter != "none" && c.Tracing.Exporter != "stdout" {
+		errs = append(errs, fmt.Errorf("tracing exporter must be none or stdout, got %q", c.Tracing.Exporter))
+	}
+	if c.Tracing.ServiceName == "" {
+		errs = append(errs, errors.New("tracing serviceName is required (TRACING_SERVICE_NAME)"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// envReader parses environment variables into settings, collecting the
+// errors of the invalid ones.
+type envReader struct {
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+func (e *envReader) string(name string, target *string) {
+	if value, ok := e.lookup(name); ok {
+		*target = value
+	}
+}
+
+func (e *envReader) int(name string, target *int) {
+	value, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be an integer, got %q", name, value))
+		return
+	}
+
+	*target = n
+}
+
+func (e *envReader) duration(name string, target *time.Duration) {
+	value, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be a duration such as 10s, got %q", name, value))
+		return
+	}
+
+	*target = d
+}
+
+func (e *envReader) bool(name string, target *bool) {
+	value, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s must be true or false, got %q", name, value))
+		return
+	}
+
+	*target = b
+}
+
+func (e *envReader) list(name string, target *[]string) {
+	value, ok := e.lookup(name)
+	if !ok {
+		return
+	}
+
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	*target = items
+}
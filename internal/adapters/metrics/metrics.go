@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+const namespace = "survey"
+
+// Metrics holds the Prometheus metrics of the service.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests      *prometheus.HistogramVec
+	commandDuration   *prometheus.HistogramVec
+	commandErrors     *prometheus.CounterVec
+	repositoryLatency *prometheus.HistogramVec
+	conflicts         *prometheus.CounterVec
+	eventsAppended    *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route and status.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		commandDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "command_duration_seconds",
+			Help:      "Duration of command handling by command.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"command"}),
+		commandErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "command_errors_total",
+			Help:      "Failed commands by command and error type.",
+		}, []string{"command", "error"}),
+		repositoryLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_operation_duration_seconds",
+			Help:      "Duration of repository operations by aggregate.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"operation", "aggregate"}),
+		conflicts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "concurrency_conflicts_total",
+			Help:      "Saves rejected by optimistic concurrency control by aggregate.",
+		}, []string{"aggregate"}),
+		eventsAppended: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "events_appended_total",
+			Help:      "Events stored by type.",
+		}, []string{"type"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.commandDuration,
+		m.commandErrors,
+		m.repositoryLatency,
+		m.conflicts,
+		m.eventsAppended,
+	)
+
+	return m
+}
+
+// MustRegister adds collectors to the metrics served by Handler.
+func (m *Metrics) MustRegister(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Middleware records the duration of HTTP requests. Requests are labelled
+// with the route pattern rather than the path, so ids don't create new
+// series.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(rec, r)
+
+		status := rec.Status()
+		if status == 0 {
+			// Nothing was written, which net/http answers with 200 OK.
+			status = http.StatusOK
+		}
+
+		route := "unmatched"
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			route = rctx.RoutePattern()
+		}
+
+		m.httpRequests.
+			WithLabelValues(r.Method, route, strconv.Itoa(status)).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// ObserveCommand implements core.CommandObserver.
+func (m *Metrics) ObserveCommand(ctx context.Context, name string) (context.Context, func(err error)) {
+	start := time.Now()
+
+	return ctx, func(err error) {
+		m.commandDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+		if err != nil {
+			m.commandErrors.WithLabelValues(name, errorType(err)).Inc()
+		}
+	}
+}
+
+// errorType classifies errors into a small set of label values.
+func errorType(err error) string {
+	switch {
+	case errors.Is(err, core.ErrConcurrencyConflict):
+		return "concurrency_conflict"
+	case errors.Is(err, core.ErrDuplicateKey):
+		return "duplicate_key"
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return "unauthenticated"
+	case errors.Is(err, auth.ErrForbidden):
+		return "forbidden"
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, context.Canceled):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
+
+// OutboxCounter counts the outbox messages by status.
+type OutboxCounter func(ctx context.Context) (map[string]int, error)
+
+// OutboxCollector reports the pending and failed outbox messages when
+// scraped.
+func OutboxCollector(count OutboxCounter) prometheus.Collector {
+	return &outboxCollector{
+		count: count,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "outbox", "messages"),
+			"Outbox messages by status.",
+			[]string{"status"},
+			nil,
+		),
+	}
+}
+
+type outboxCollector struct {
+	count OutboxCounter
+	desc  *prometheus.Desc
+}
+
+func (c *outboxCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *outboxCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+
+	for _, status := range []string{"pending", "failed"} {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(counts[status]), status)
+	}
+}
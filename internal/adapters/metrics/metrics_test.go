@@ -0,0 +1,151 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/adapters/metrics"
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	t.Run("labels requests with the route pattern", func(t *testing.T) {
+		m := metrics.New()
+
+		r := chi.NewRouter()
+		r.Use(m.Middleware)
+		r.Get("/surveys/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/surveys/123", nil))
+
+		assert.Contains(t, scrape(t, m), `survey_http_request_duration_seconds_count{method="GET",route="/surveys/{id}",status="404"} 1`)
+	})
+
+	t.Run("counts command errors by type", func(t *testing.T) {
+		m := metrics.New()
+		bus := core.NewCommandBus(core.ObserverMiddleware(m))
+		core.HandleFunc(bus, func(ctx context.Context, cmd pingCommand) error {
+			return cmd.err
+		})
+
+		_, _ = bus.Dispatch(context.Background(), pingCommand{})
+		_, _ = bus.Dispatch(context.Background(), pingCommand{err: auth.ErrForbidden})
+
+		out := scrape(t, m)
+		assert.Contains(t, out, `survey_command_duration_seconds_count{command="ping"} 2`)
+		assert.Contains(t, out, `survey_command_errors_total{command="ping",error="forbidden"} 1`)
+	})
+
+	t.Run("records repository operations", func(t *testing.T) {
+		m := metrics.New()
+		tx := m.TransactionProvider(&stubTransactionProvider{saveErr: core.ErrConcurrencyConflict})
+
+		_ = tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), newPingAggregate())
+		})
+
+		out := scrape(t, m)
+		assert.Contains(t, out, `survey_repository_operation_duration_seconds_count{aggregate="ping",operation="save"} 1`)
+		assert.Contains(t, out, `survey_concurrency_conflicts_total{aggregate="ping"} 1`)
+		assert.NotContains(t, out, `survey_events_appended_total`)
+	})
+
+	t.Run("counts events of succeeding transactions", func(t *testing.T) {
+		m := metrics.New()
+		tx := m.TransactionProvider(&stubTransactionProvider{})
+
+		_ = tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), newPingAggregate())
+		})
+		_ = tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			err := repo.Save(context.Background(), newPingAggregate())
+			if err != nil {
+				return err
+			}
+			return errors.New("rolled back")
+		})
+
+		assert.Contains(t, scrape(t, m), `survey_events_appended_total{type="pinged"} 1`)
+	})
+
+	t.Run("reports outbox messages", func(t *testing.T) {
+		m := metrics.New()
+		m.MustRegister(metrics.OutboxCollector(func(ctx context.Context) (map[string]int, error) {
+			return map[string]int{"pending": 3, "sent": 10}, nil
+		}))
+
+		out := scrape(t, m)
+		assert.Contains(t, out, `survey_outbox_messages{status="pending"} 3`)
+		assert.Contains(t, out, `survey_outbox_messages{status="failed"} 0`)
+	})
+}
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	res := httptest.NewRecorder()
+	m.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, res.Code)
+
+	return res.Body.String()
+}
+
+type pingCommand struct {
+	err error
+}
+
+func (pingCommand) CommandName() string { return "ping" }
+
+type pinged struct {
+	Id core.AggregateId
+}
+
+func (e pinged) AggregateId() core.AggregateId { return e.Id }
+func (e pinged) Type() string                  { return "pinged" }
+func (e pinged) OccurredAt() time.Time         { return time.Time{} }
+
+type pingAggregate struct {
+	core.BaseAggregate
+	Id core.AggregateId
+}
+
+func newPingAggregate() *pingAggregate {
+	a := &pingAggregate{Id: core.NewAggregateId()}
+	a.AddDomainEvent(pinged{Id: a.Id})
+	return a
+}
+
+func (a *pingAggregate) ID() core.AggregateId { return a.Id }
+func (a *pingAggregate) Name() string         { return "ping" }
+func (a *pingAggregate) TableName() string    { return "pings" }
+
+type stubTransactionProvider struct {
+	saveErr error
+}
+
+func (p *stubTransactionProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	return fn(p)
+}
+
+func (p *stubTransactionProvider) Save(ctx context.Context, aggregate core.Aggregate) error {
+	return p.saveErr
+}
+
+func (p *stubTransactionProvider) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	return nil
+}
+
+func (p *stubTransactionProvider) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return nil
+}
+
+func (p *stubTransactionProvider) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return nil
+}
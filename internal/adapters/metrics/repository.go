@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+// TransactionProvider records the latency of the repository operations run
+// in its transactions, the concurrency conflicts they hit and the events
+// of the transactions that succeed.
+type TransactionProvider struct {
+	next    core.TransactionProvider
+	metrics *Metrics
+}
+
+func (m *Metrics) TransactionProvider(next core.TransactionProvider) *TransactionProvider {
+	return &TransactionProvider{next: next, metrics: m}
+}
+
+func (p *TransactionProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	var saved []core.DomainEvent
+
+	err := p.next.RunTransactional(ctx, func(repo core.Repository) error {
+		r := &repository{Repository: repo, metrics: p.metrics}
+
+		err := fn(r)
+		saved = r.events
+
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, event := range saved {
+		p.metrics.eventsAppended.WithLabelValues(event.Type()).Inc()
+	}
+
+	return nil
+}
+
+func (p *TransactionProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	next, ok := p.next.(core.ContextTransactionProvider)
+	if !ok {
+		return core.ErrContextTransactionsUnsupported
+	}
+
+	return next.WithTransaction(ctx, fn)
+}
+
+type repository struct {
+	core.Repository
+	metrics *Metrics
+	events  []core.DomainEvent
+}
+
+func (r *repository) Save(ctx context.Context, aggregate core.Aggregate) error {
+	events := aggregate.GetUncommittedEvents()
+	start := time.Now()
+
+	err := r.Repository.Save(ctx, aggregate)
+
+	r.metrics.repositoryLatency.WithLabelValues("save", aggregate.Name()).Observe(time.Since(start).Seconds())
+
+	if errors.Is(err, core.ErrConcurrencyConflict) {
+		r.metrics.conflicts.WithLabelValues(aggregate.Name()).Inc()
+	}
+
+	if err != nil {
+		return err
+	}
+
+	r.events = append(r.events, events...)
+
+	return nil
+}
+
+func (r *repository) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	start := time.Now()
+
+	err := r.Repository.Load(ctx, id, aggregate)
+
+	r.metrics.repositoryLatency.WithLabelValues("load", aggregate.Name()).Observe(time.Since(start).Seconds())
+
+	return err
+}
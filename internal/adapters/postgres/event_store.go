@@ -0,0 +1,83 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+type PostgresEventStore struct {
+	db     *sql.DB
+	events *core.EventRegistry
+}
+
+func NewPostgresEventStore(db *sql.DB, events *core.EventRegistry) *PostgresEventStore {
+	return &PostgresEventStore{db: db, events: events}
+}
+
+func (s *PostgresEventStore) ReadEvents(ctx context.Context, id core.AggregateId, after int, limit int) ([]core.StoredEvent, error) {
+	rows, err := s.db.QueryContext(ctx, `
+        SELECT aggregate_id, aggregate_name, event_type, schema_version, payload, occurred_at, version,
+               actor_id, tenant_id, correlation_id, causation_id, request_id
+        FROM events
+        WHERE aggregate_id = $1 AND version > $2
+        ORDER BY version
+        LIMIT $3
+    `, uuid.UUID(id), after, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]core.StoredEvent, 0)
+
+	for rows.Next() {
+		var event core.StoredEvent
+		var schemaVersion int
+		var payload []byte
+		var actorId, tenantId, correlationId, causationId, requestId sql.NullString
+
+		err = rows.Scan(
+			&event.AggregateId,
+			&event.AggregateName,
+			&event.Type,
+			&schemaVersion,
+			&payload,
+			&event.OccurredAt,
+			&event.Version,
+			&actorId,
+			&tenantId,
+			&correlationId,
+			&causationId,
+			&requestId,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		// Events no longer known to the registry are returned as stored.
+		upcast, err := s.events.Upcast(event.Type, schemaVersion, payload)
+		if errors.Is(err, core.ErrUnknownEventType) {
+			upcast = payload
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read event %d of %s: %w", event.Version, id, err)
+		}
+
+		event.Payload = upcast
+		event.Metadata = core.Metadata{
+			ActorId:       actorId.String,
+			TenantId:      tenantId.String,
+			CorrelationId: correlationId.String,
+			CausationId:   causationId.String,
+			RequestId:     requestId.String,
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
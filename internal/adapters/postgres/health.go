@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// OutboxBacklogCheck fails when more than threshold outbox messages are
+// waiting to be relayed.
+func OutboxBacklogCheck(db *sql.DB, threshold int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var pending int
+
+		err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE status = 'pending'`).Scan(&pending)
+		if err != nil {
+			return err
+		}
+
+		if pending > threshold {
+			return fmt.Errorf("%d pending outbox messages, more than %d", pending, threshold)
+		}
+
+		return nil
+	}
+}
+
+// OutboxCounts returns the number of outbox messages by status.
+func OutboxCounts(db *sql.DB) func(ctx context.Context) (map[string]int, error) {
+	return func(ctx context.Context) (map[string]int, error) {
+		rows, err := db.QueryContext(ctx, `SELECT status, COUNT(*) FROM outbox GROUP BY status`)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		counts := make(map[string]int)
+
+		for rows.Next() {
+			var status string
+			var count int
+
+			if err := rows.Scan(&status, &count); err != nil {
+				return nil, err
+			}
+
+			counts[status] = count
+		}
+
+		return counts, rows.Err()
+	}
+}
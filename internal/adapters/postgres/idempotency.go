@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/ports"
+)
+
+type PostgresIdempotencyStore struct {
+	db *sql.DB
+}
+
+func NewPostgresIdempotencyStore(db *sql.DB) *PostgresIdempotencyStore {
+	return &PostgresIdempotencyStore{db: db}
+}
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// querier returns the transaction in the context, so the record is written
+// atomically with the command it belongs to.
+func (s *PostgresIdempotencyStore) querier(ctx context.Context) querier {
+	if active, ok := txFromContext(ctx); ok {
+		return active.tx
+	}
+
+	return s.db
+}
+
+func (s *PostgresIdempotencyStore) Reserve(
+	ctx context.Context,
+	key string,
+	requestHash string,
+	expiredBefore time.Time,
+) (*ports.IdempotencyRecord, error) {
+	q := s.querier(ctx)
+
+	_, err := q.ExecContext(ctx, `
+        DELETE FROM idempotency_keys WHERE key = $1 AND created_at < $2
+    `, key, expiredBefore)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := q.ExecContext(ctx, `
+        INSERT INTO idempotency_keys (key, request_hash, created_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (key) DO NOTHING
+    `, key, requestHash, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 1 {
+		return nil, nil
+	}
+
+	var record ports.IdempotencyRecord
+	var statusCode sql.NullInt64
+	var contentType sql.NullString
+
+	err = q.QueryRowContext(ctx, `
+        SELECT key, request_hash, status_code, content_type, body, created_at
+        FROM idempotency_keys WHERE key = $1
+    `, key).Scan(
+		&record.Key,
+		&record.RequestHash,
+		&statusCode,
+		&contentType,
+		&record.Body,
+		&record.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	record.StatusCode = int(statusCode.Int64)
+	record.ContentType = contentType.String
+
+	return &record, nil
+}
+
+func (s *PostgresIdempotencyStore) Complete(ctx context.Context, record ports.IdempotencyRecord) error {
+	_, err := s.querier(ctx).ExecContext(ctx, `
+        UPDATE idempotency_keys
+        SET status_code = $1, content_type = $2, body = $3
+        WHERE key = $4
+    `, record.StatusCode, record.ContentType, record.Body, record.Key)
+
+	return err
+}
+
+func (s *PostgresIdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	res, err := s.querier(ctx).ExecContext(ctx, `
+        DELETE FROM idempotency_keys WHERE created_at < $1
+    `, before)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+type PostgresInvitationReader struct {
+	db *sql.DB
+}
+
+func NewPostgresInvitationReader(db *sql.DB) *PostgresInvitationReader {
+	return &PostgresInvitationReader{db: db}
+}
+
+func (r *PostgresInvitationReader) ListInvitations(ctx context.Context, surveyId surveys.SurveyId) ([]surveys.Invitation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT data, version, created_at FROM invitations
+        WHERE data->>'surveyId' = $1
+        ORDER BY created_at, id
+    `, surveyId.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := make([]surveys.Invitation, 0)
+
+	for rows.Next() {
+		var data []byte
+		var version int
+		var createdAt time.Time
+
+		err = rows.Scan(&data, &version, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		var invitation surveys.Invitation
+
+		err = json.Unmarshal(data, &invitation)
+		if err != nil {
+			return nil, err
+		}
+
+		invitation.SetVersion(version)
+		invitation.SetCreatedAt(createdAt)
+
+		invitations = append(invitations, invitation)
+	}
+
+	return invitations, rows.Err()
+}
@@ -0,0 +1,90 @@
+package postgres_test
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres"
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres/postgrestest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMigrations(t *testing.T) {
+	t.Run("orders migrations by version", func(t *testing.T) {
+		migrations, err := postgres.ParseMigrations(fstest.MapFS{
+			"0002_add_index.up.sql":   {Data: []byte("CREATE INDEX")},
+			"0001_init.up.sql":        {Data: []byte("CREATE TABLE")},
+			"0001_init.down.sql":      {Data: []byte("DROP TABLE")},
+			"0010_add_column.up.sql":  {Data: []byte("ALTER TABLE")},
+			"0002_add_index.down.sql": {Data: []byte("DROP INDEX")},
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []postgres.Migration{
+			{Version: 1, Name: "init", Up: "CREATE TABLE", Down: "DROP TABLE"},
+			{Version: 2, Name: "add_index", Up: "CREATE INDEX", Down: "DROP INDEX"},
+			{Version: 10, Name: "add_column", Up: "ALTER TABLE"},
+		}, migrations)
+	})
+
+	t.Run("rejects invalid migrations", func(t *testing.T) {
+		for name, fsys := range map[string]fstest.MapFS{
+			"unknown file":   {"init.sql": {Data: []byte("CREATE TABLE")}},
+			"missing up":     {"0001_init.down.sql": {Data: []byte("DROP TABLE")}},
+			"name conflicts": {"0001_init.up.sql": {}, "0001_other.down.sql": {}},
+		} {
+			_, err := postgres.ParseMigrations(fsys)
+			assert.NotNil(t, err, name)
+		}
+	})
+
+	t.Run("embedded migrations are valid", func(t *testing.T) {
+		_, err := postgres.NewMigrator(nil)
+		assert.Nil(t, err)
+	})
+}
+
+func TestMigratorIntegration(t *testing.T) {
+	t.Run("migrations can be rolled back and applied again", func(t *testing.T) {
+		db := postgrestest.New(t)
+		ctx := context.Background()
+
+		migrator, err := postgres.NewMigrator(db)
+		assert.Nil(t, err)
+		assert.Nil(t, migrator.CheckApplied(ctx))
+
+		status, err := migrator.Status(ctx)
+		assert.Nil(t, err)
+
+		reverted, err := migrator.Down(ctx, len(status))
+		assert.Nil(t, err)
+		assert.Len(t, reverted, len(status))
+		assert.NotNil(t, migrator.CheckApplied(ctx))
+
+		applied, err := migrator.Up(ctx)
+		assert.Nil(t, err)
+		assert.Len(t, applied, len(status))
+		assert.Nil(t, migrator.CheckApplied(ctx))
+	})
+
+	t.Run("status doesn't create the migrations table", func(t *testing.T) {
+		db := postgrestest.NewEmpty(t)
+		ctx := context.Background()
+
+		migrator, err := postgres.NewMigrator(db)
+		assert.Nil(t, err)
+
+		status, err := migrator.Status(ctx)
+		assert.Nil(t, err)
+		assert.NotEmpty(t, status)
+		for _, migration := range status {
+			assert.Nil(t, migration.AppliedAt)
+		}
+
+		var exists bool
+		err = db.QueryRowContext(ctx, `SELECT to_regclass('schema_migrations') IS NOT NULL`).Scan(&exists)
+		assert.Nil(t, err)
+		assert.False(t, exists)
+	})
+}
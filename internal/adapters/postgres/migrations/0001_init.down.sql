@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS outbox;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS survey_responses;
+DROP TABLE IF EXISTS surveys;
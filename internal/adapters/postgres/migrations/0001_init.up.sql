@@ -1,11 +1,14 @@
-CREATE TABLE surveys (
+-- Baseline schema, as set up before migrations existed. Tables are created
+-- only if missing, so databases set up back then are adopted as they are.
+
+CREATE TABLE IF NOT EXISTS surveys (
     id UUID PRIMARY KEY,
     data JSONB NOT NULL,
     version INT NOT NULL,
     created_at TIMESTAMP NOT NULL
 );
 
-CREATE TABLE survey_responses (
+CREATE TABLE IF NOT EXISTS survey_responses (
     id UUID PRIMARY KEY,
     data JSONB NOT NULL,
     version INT NOT NULL,
@@ -23,9 +26,9 @@ CREATE TABLE IF NOT EXISTS events (
     CONSTRAINT unique_aggregate_version UNIQUE (aggregate_id, version)
 );
 
-CREATE INDEX idx_events_aggregate_id ON events (aggregate_id);
-CREATE INDEX idx_events_type ON events (event_type);
-CREATE INDEX idx_events_occurred_at ON events (occurred_at);
+CREATE INDEX IF NOT EXISTS idx_events_aggregate_id ON events (aggregate_id);
+CREATE INDEX IF NOT EXISTS idx_events_type ON events (event_type);
+CREATE INDEX IF NOT EXISTS idx_events_occurred_at ON events (occurred_at);
 
 CREATE TABLE IF NOT EXISTS outbox (
     id BIGSERIAL PRIMARY KEY,
@@ -37,4 +40,4 @@ CREATE TABLE IF NOT EXISTS outbox (
     status TEXT NOT NULL
 );
 
-CREATE INDEX IF NOT EXISTS idx_outbox_occurred_at ON outbox (occurred_at);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_outbox_occurred_at ON outbox (occurred_at);
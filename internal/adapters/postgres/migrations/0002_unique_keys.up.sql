@@ -0,0 +1,7 @@
+-- Values such as respondent ids that only one aggregate may hold.
+CREATE TABLE IF NOT EXISTS unique_keys (
+    scope VARCHAR(255) NOT NULL,
+    value TEXT NOT NULL,
+    aggregate_id UUID NOT NULL,
+    PRIMARY KEY (scope, value)
+);
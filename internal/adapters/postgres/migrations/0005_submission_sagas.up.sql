@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS submission_sagas (
+    id UUID PRIMARY KEY,
+    data JSONB NOT NULL,
+    version INT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
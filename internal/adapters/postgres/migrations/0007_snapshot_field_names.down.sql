@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_submission_sagas_status;
+DROP INDEX IF EXISTS idx_invitations_survey_id;
+
+UPDATE submission_sagas
+SET data = data - 'status'
+WHERE data ? 'Status' AND data ? 'status';
+
+UPDATE invitations
+SET data = data - 'surveyId'
+WHERE data ? 'SurveyId' AND data ? 'surveyId';
@@ -0,0 +1,19 @@
+-- Invitations and submission sagas saved before snapshots got explicit JSON
+-- tags have Go field names. Copy the fields queried in SQL to their current
+-- names, so the indexes and filters find those rows too.
+
+UPDATE invitations
+SET data = data || jsonb_build_object('surveyId', data->'SurveyId')
+WHERE data ? 'SurveyId' AND NOT data ? 'surveyId';
+
+UPDATE submission_sagas
+SET data = data || jsonb_build_object('status', data->'Status')
+WHERE data ? 'Status' AND NOT data ? 'status';
+
+-- Databases set up before migrations existed may have the indexes on the
+-- Go field names.
+DROP INDEX IF EXISTS idx_invitations_survey_id;
+DROP INDEX IF EXISTS idx_submission_sagas_status;
+
+CREATE INDEX idx_invitations_survey_id ON invitations ((data->>'surveyId'));
+CREATE INDEX idx_submission_sagas_status ON submission_sagas ((data->>'status'));
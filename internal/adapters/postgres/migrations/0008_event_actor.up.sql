@@ -0,0 +1,2 @@
+-- The user whose command produced the event, NULL for system changes.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS actor_id TEXT;
@@ -0,0 +1,12 @@
+ALTER TABLE outbox DROP COLUMN IF EXISTS request_id;
+ALTER TABLE outbox DROP COLUMN IF EXISTS causation_id;
+ALTER TABLE outbox DROP COLUMN IF EXISTS correlation_id;
+ALTER TABLE outbox DROP COLUMN IF EXISTS tenant_id;
+ALTER TABLE outbox DROP COLUMN IF EXISTS actor_id;
+
+DROP INDEX IF EXISTS idx_events_correlation_id;
+
+ALTER TABLE events DROP COLUMN IF EXISTS request_id;
+ALTER TABLE events DROP COLUMN IF EXISTS causation_id;
+ALTER TABLE events DROP COLUMN IF EXISTS correlation_id;
+ALTER TABLE events DROP COLUMN IF EXISTS tenant_id;
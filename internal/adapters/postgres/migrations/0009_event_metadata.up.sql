@@ -0,0 +1,13 @@
+-- Tenant and request correlation of stored and published events.
+ALTER TABLE events ADD COLUMN IF NOT EXISTS tenant_id TEXT;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS correlation_id TEXT;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS causation_id TEXT;
+ALTER TABLE events ADD COLUMN IF NOT EXISTS request_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_events_correlation_id ON events (correlation_id);
+
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS actor_id TEXT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS tenant_id TEXT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS correlation_id TEXT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS causation_id TEXT;
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS request_id TEXT;
@@ -0,0 +1,3 @@
+-- W3C trace context of the request that produced the message, so the work
+-- of consumers links back to it.
+ALTER TABLE outbox ADD COLUMN IF NOT EXISTS trace_context JSONB;
@@ -0,0 +1,2 @@
+-- The readiness check and metrics count outbox messages by status.
+CREATE INDEX IF NOT EXISTS idx_outbox_status ON outbox (status);
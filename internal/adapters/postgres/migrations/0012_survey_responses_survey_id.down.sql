@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_survey_responses_survey_id;
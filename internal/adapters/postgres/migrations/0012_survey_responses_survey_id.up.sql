@@ -0,0 +1,2 @@
+-- Results and response listings select the responses of a survey.
+CREATE INDEX IF NOT EXISTS idx_survey_responses_survey_id ON survey_responses ((data->>'surveyId'));
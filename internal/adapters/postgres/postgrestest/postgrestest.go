@@ -0,0 +1,85 @@
+// Package postgrestest provides Postgres databases for integration tests.
+package postgrestest
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres"
+)
+
+// New returns a connection to a schema of its own in the database at
+// TEST_DATABASE_URL, with the migrations applied. The test is skipped when
+// the variable isn't set, and the schema is dropped when the test ends.
+func New(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db := NewEmpty(t)
+
+	migrator, err := postgres.NewMigrator(db)
+	if err != nil {
+		t.Fatalf("failed to read migrations: %v", err)
+	}
+
+	_, err = migrator.Up(context.Background())
+	if err != nil {
+		t.Fatalf("failed to migrate test schema: %v", err)
+	}
+
+	return db
+}
+
+// NewEmpty is like New, but leaves the schema without any tables.
+func NewEmpty(t testing.TB) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatalf("failed to open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = admin.Close() })
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+
+	_, err = admin.Exec(`CREATE SCHEMA ` + schema)
+	if err != nil {
+		t.Fatalf("failed to create schema: %v", err)
+	}
+	t.Cleanup(func() {
+		_, _ = admin.Exec(`DROP SCHEMA ` + schema + ` CASCADE`)
+	})
+
+	db, err := sql.Open("postgres", withSearchPath(dsn, schema))
+	if err != nil {
+		t.Fatalf("failed to open test schema: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	return db
+}
+
+// withSearchPath makes the connections of dsn use the schema. Both URL and
+// key=value connection strings are accepted.
+func withSearchPath(dsn string, schema string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return dsn + " search_path=" + schema
+	}
+
+	query := u.Query()
+	query.Set("search_path", schema)
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
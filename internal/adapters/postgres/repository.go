@@ -4,8 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,43 +13,63 @@ import (
 )
 
 type PostgresRepository struct {
-	tx *sql.Tx
+	tx     *sql.Tx
+	events *core.EventRegistry
+	logger *slog.Logger
 }
 
-func NewPostgresRepository(tx *sql.Tx) *PostgresRepository {
-	return &PostgresRepository{tx: tx}
+func NewPostgresRepository(tx *sql.Tx, events *core.EventRegistry, logger *slog.Logger) *PostgresRepository {
+	return &PostgresRepository{tx: tx, events: events, logger: logger}
 }
 
-func (r *PostgresRepository) Save(ctx context.Context, aggregate core.Aggregate) error {
+func (r *PostgresRepository) Save(ctx context.Context, aggregate core.Aggregate) (err error) {
+	events := aggregate.GetUncommittedEvents()
+
+	// Every event takes the next version of the aggregate, so the version of
+	// an aggregate is the version of its latest event and can be used to
+	// rebuild it with LoadAt.
+	currentVersion := aggregate.Version()
+	newVersion := currentVersion + max(len(events), 1)
+
+	// The snapshot records the version it's stored with.
+	aggregate.SetVersion(newVersion)
+	defer func() {
+		if err != nil {
+			aggregate.SetVersion(currentVersion)
+		}
+	}()
+
 	data, err := json.Marshal(aggregate)
 	if err != nil {
 		return err
 	}
 
-	currentVersion := aggregate.Version()
-	newVersion := currentVersion + 1
-
 	if currentVersion == 0 {
 		// New aggregate: INSERT
-		_, err = r.tx.ExecContext(ctx,
+		_, err = r.exec(ctx, "INSERT "+aggregate.TableName(),
 			fmt.Sprintf(`INSERT INTO %s (id, data, version, created_at)
                          VALUES ($1, $2, $3, $4)`, aggregate.TableName()),
 			uuid.UUID(aggregate.ID()),
 			data,
-			1, // First version number is 1
+			newVersion,
 			aggregate.CreatedAt(),
 		)
 		if err != nil {
 			return fmt.Errorf("insert failed: %w", err)
 		}
+
+		err = r.reserveUniqueKeys(ctx, aggregate)
+		if err != nil {
+			return err
+		}
 	} else {
 		// Existing aggregate: UPDATE with OCC
-		res, err := r.tx.ExecContext(ctx,
+		res, err := r.exec(ctx, "UPDATE "+aggregate.TableName(),
 			fmt.Sprintf(`UPDATE %s
                          SET data = $1, version = $2
                          WHERE id = $3 AND version = $4`, aggregate.TableName()),
 			data,
-			newVersion, // e.g., version 2
+			newVersion,
 			uuid.UUID(aggregate.ID()),
 			currentVersion, // e.g., expecting version 1
 		)
@@ -62,12 +82,21 @@ func (r *PostgresRepository) Save(ctx context.Context, aggregate core.Aggregate)
 			return fmt.Errorf("rows affected error: %w", err)
 		}
 		if rowsAffected == 0 {
-			return errors.New("optimistic concurrency conflict: aggregate has been modified")
+			r.logger.InfoContext(ctx, "concurrency conflict",
+				slog.String("aggregate", aggregate.Name()),
+				slog.String("aggregateId", aggregate.ID().String()),
+				slog.Int("expectedVersion", currentVersion),
+			)
+			return core.ErrConcurrencyConflict
 		}
 	}
 
-	events := aggregate.GetUncommittedEvents()
-	baseVersion := currentVersion
+	metadata := core.MetadataFromContext(ctx)
+
+	traceCtx, err := traceContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to encode trace context: %w", err)
+	}
 
 	for i, event := range events {
 		eventData, err := json.Marshal(event)
@@ -75,39 +104,98 @@ func (r *PostgresRepository) Save(ctx context.Context, aggregate core.Aggregate)
 			return fmt.Errorf("failed to marshal event: %w", err)
 		}
 
-		version := baseVersion + i + 1
+		version := currentVersion + i + 1
+		schemaVersion := r.events.SchemaVersion(event.Type())
 
-		_, err = r.tx.ExecContext(ctx, `
-            INSERT INTO events (aggregate_id, aggregate_name, event_type, payload, occurred_at, version)
-            VALUES ($1, $2, $3, $4, $5, $6)
+		_, err = r.exec(ctx, "INSERT events", `
+            INSERT INTO events (aggregate_id, aggregate_name, event_type, schema_version, payload, occurred_at, version,
+                                actor_id, tenant_id, correlation_id, causation_id, request_id)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         `,
 			uuid.UUID(aggregate.ID()),
 			aggregate.Name(),
 			event.Type(),
+			schemaVersion,
 			eventData,
 			event.OccurredAt(),
 			version,
+			nullString(metadata.ActorId),
+			nullString(metadata.TenantId),
+			nullString(metadata.CorrelationId),
+			nullString(metadata.CausationId),
+			nullString(metadata.RequestId),
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert event: %w", err)
 		}
 
-		_, err = r.tx.ExecContext(ctx, `
-            INSERT INTO outbox (aggregate_id, aggregate_name, event_type, payload, occurred_at, status)
-            VALUES ($1, $2, $3, $4, $5, $6)
+		// Outbox messages carry the same payload, schema version and metadata
+		// as the stored event, so consumers can upcast them with the same
+		// registry and keep the correlation going. The trace context links
+		// their work to the trace of the request.
+		_, err = r.exec(ctx, "INSERT outbox", `
+            INSERT INTO outbox (aggregate_id, aggregate_name, event_type, schema_version, payload, occurred_at, status,
+                                actor_id, tenant_id, correlation_id, causation_id, request_id, trace_context)
+            VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
         `,
 			uuid.UUID(aggregate.ID()),
 			aggregate.Name(),
 			event.Type(),
+			schemaVersion,
 			eventData,
 			time.Now(),
 			"pending",
+			nullString(metadata.ActorId),
+			nullString(metadata.TenantId),
+			nullString(metadata.CorrelationId),
+			nullString(metadata.CausationId),
+			nullString(metadata.RequestId),
+			traceCtx,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to insert outbox entry: %w", err)
 		}
 	}
 
+	r.logger.DebugContext(ctx, "aggregate saved",
+		slog.String("aggregate", aggregate.Name()),
+		slog.String("aggregateId", aggregate.ID().String()),
+		slog.Int("version", newVersion),
+		slog.Int("events", len(events)),
+	)
+
+	return nil
+}
+
+func (r *PostgresRepository) reserveUniqueKeys(ctx context.Context, aggregate core.Aggregate) error {
+	holder, ok := aggregate.(core.UniqueKeyHolder)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range holder.UniqueKeys() {
+		res, err := r.exec(ctx, "INSERT unique_keys", `
+            INSERT INTO unique_keys (scope, value, aggregate_id)
+            VALUES ($1, $2, $3)
+            ON CONFLICT DO NOTHING
+        `,
+			key.Scope,
+			key.Value,
+			uuid.UUID(aggregate.ID()),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to reserve unique key: %w", err)
+		}
+
+		rowsAffected, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("rows affected error: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: %s", core.ErrDuplicateKey, key.Scope)
+		}
+	}
+
 	return nil
 }
 
@@ -116,7 +204,7 @@ func (r *PostgresRepository) Load(ctx context.Context, id core.AggregateId, agg
 	var version int
 	var createdAt time.Time
 
-	err := r.tx.QueryRowContext(ctx,
+	err := r.queryRow(ctx, "SELECT "+agg.TableName(),
 		fmt.Sprintf(`SELECT data, version, created_at FROM %s WHERE id = $1`, agg.TableName()),
 		id,
 	).Scan(&data, &version, &createdAt)
@@ -134,3 +222,67 @@ func (r *PostgresRepository) Load(ctx context.Context, id core.AggregateId, agg
 
 	return nil
 }
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func (r *PostgresRepository) LoadAt(ctx context.Context, id core.AggregateId, version int, agg core.Aggregate) error {
+	return r.replay(ctx, agg, `
+        SELECT event_type, schema_version, payload, version
+        FROM events
+        WHERE aggregate_id = $1 AND version <= $2
+        ORDER BY version
+    `, uuid.UUID(id), version)
+}
+
+func (r *PostgresRepository) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, agg core.Aggregate) error {
+	return r.replay(ctx, agg, `
+        SELECT event_type, schema_version, payload, version
+        FROM events
+        WHERE aggregate_id = $1 AND occurred_at <= $2
+        ORDER BY version
+    `, uuid.UUID(id), asOf)
+}
+
+// replay applies the events selected by the query to the aggregate. It
+// fails with sql.ErrNoRows when the aggregate has no events at that point.
+func (r *PostgresRepository) replay(ctx context.Context, agg core.Aggregate, query string, args ...any) error {
+	sourced, ok := agg.(core.EventSourced)
+	if !ok {
+		return core.ErrNotEventSourced
+	}
+
+	version := 0
+
+	err := r.query(ctx, "SELECT events", query, func(rows *sql.Rows) error {
+		var eventType string
+		var schemaVersion int
+		var payload []byte
+
+		err := rows.Scan(&eventType, &schemaVersion, &payload, &version)
+		if err != nil {
+			return err
+		}
+
+		event, err := r.events.Decode(eventType, schemaVersion, payload)
+		if err != nil {
+			return fmt.Errorf("failed to replay event %d: %w", version, err)
+		}
+
+		sourced.ApplyEvent(event)
+
+		return nil
+	}, args...)
+	if err != nil {
+		return err
+	}
+
+	if version == 0 {
+		return sql.ErrNoRows
+	}
+
+	agg.SetVersion(version)
+
+	return nil
+}
@@ -0,0 +1,193 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres"
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres/postgrestest"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestRepositoryIntegration(t *testing.T) {
+	t.Run("loads what was saved", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+
+		save(t, tx, survey)
+
+		loaded := loadSurvey(t, tx, survey.Id)
+		assert.Equal(t, survey.Title, loaded.Title)
+		assert.Equal(t, survey.Version(), loaded.Version())
+	})
+
+	t.Run("updates bump the version by the events saved", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+		save(t, tx, survey)
+		created := survey.Version()
+
+		err := core.Update(context.Background(), tx, core.AggregateId(survey.Id), func(s *surveys.Survey) error {
+			return s.SetMaxParticipants(10)
+		})
+		assert.Nil(t, err)
+
+		loaded := loadSurvey(t, tx, survey.Id)
+		assert.Equal(t, 10, loaded.MaxParticipants)
+		assert.Equal(t, created+1, loaded.Version())
+	})
+
+	t.Run("rejects saving a stale aggregate", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+		save(t, tx, survey)
+
+		first := loadSurvey(t, tx, survey.Id)
+		second := loadSurvey(t, tx, survey.Id)
+
+		_ = first.SetMaxParticipants(10)
+		save(t, tx, first)
+
+		_ = second.SetMaxParticipants(20)
+		err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), second)
+		})
+		assert.ErrorIs(t, err, core.ErrConcurrencyConflict)
+		assert.Equal(t, first.Version()-1, second.Version())
+
+		assert.Equal(t, 10, loadSurvey(t, tx, survey.Id).MaxParticipants)
+	})
+
+	t.Run("rejects a second aggregate reserving the same unique key", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+
+		save(t, tx, surveys.NewSurveyResponse(survey.Id, "user:respondent"))
+
+		err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), surveys.NewSurveyResponse(survey.Id, "user:respondent"))
+		})
+		assert.ErrorIs(t, err, core.ErrDuplicateKey)
+	})
+
+	t.Run("rebuilds past versions from the events", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+		save(t, tx, survey)
+		created := survey.Version()
+
+		_ = survey.SetMaxParticipants(10)
+		save(t, tx, survey)
+
+		past := new(surveys.Survey)
+		err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.LoadAt(context.Background(), core.AggregateId(survey.Id), created, past)
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, created, past.Version())
+		assert.NotEqual(t, 10, past.MaxParticipants)
+	})
+
+	t.Run("writes the events to the outbox with their metadata and trace", func(t *testing.T) {
+		db := postgrestest.New(t)
+		tx := newProvider(t, db)
+
+		propagator := otel.GetTextMapPropagator()
+		t.Cleanup(func() { otel.SetTextMapPropagator(propagator) })
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+		traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceId,
+			SpanID:     spanId,
+			TraceFlags: trace.FlagsSampled,
+		}))
+		ctx = core.WithMetadata(ctx, core.Metadata{ActorId: "owner", CorrelationId: "correlation"})
+
+		survey := newSurvey(t)
+		events := len(survey.GetUncommittedEvents())
+
+		err := tx.RunTransactional(ctx, func(repo core.Repository) error {
+			return repo.Save(ctx, survey)
+		})
+		assert.Nil(t, err)
+
+		rows, err := db.Query(`
+            SELECT event_type, status, actor_id, correlation_id, trace_context
+            FROM outbox
+            WHERE aggregate_id = $1
+            ORDER BY id
+        `, uuid.UUID(survey.Id))
+		assert.Nil(t, err)
+		defer rows.Close()
+
+		count := 0
+		for rows.Next() {
+			var eventType, status string
+			var actorId, correlationId sql.NullString
+			var traceContext []byte
+
+			assert.Nil(t, rows.Scan(&eventType, &status, &actorId, &correlationId, &traceContext))
+
+			var carrier map[string]string
+			assert.Nil(t, json.Unmarshal(traceContext, &carrier))
+
+			assert.NotEmpty(t, eventType)
+			assert.Equal(t, "pending", status)
+			assert.Equal(t, "owner", actorId.String)
+			assert.Equal(t, "correlation", correlationId.String)
+			assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", carrier["traceparent"])
+			count++
+		}
+		assert.Nil(t, rows.Err())
+		assert.Equal(t, events, count)
+
+		counts, err := postgres.OutboxCounts(db)(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, events, counts["pending"])
+	})
+}
+
+func newProvider(t *testing.T, db *sql.DB) *postgres.PostgresTransactionalProvider {
+	registry := core.NewEventRegistry()
+	surveys.RegisterEvents(registry)
+
+	return postgres.NewPostgresTransactionalProvider(db, registry, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func newSurvey(t *testing.T) *surveys.Survey {
+	survey, err := surveys.NewSurvey("some title", nil, "tenant", "owner")
+	assert.Nil(t, err)
+
+	return survey
+}
+
+func save(t *testing.T, tx core.TransactionProvider, aggregate core.Aggregate) {
+	err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Save(context.Background(), aggregate)
+	})
+	assert.Nil(t, err)
+
+	aggregate.ClearUncommittedEvents()
+}
+
+func loadSurvey(t *testing.T, tx core.TransactionProvider, id surveys.SurveyId) *surveys.Survey {
+	survey := new(surveys.Survey)
+
+	err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Load(context.Background(), core.AggregateId(id), survey)
+	})
+	assert.Nil(t, err)
+
+	return survey
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+type PostgresResponseReader struct {
+	db *sql.DB
+}
+
+func NewPostgresResponseReader(db *sql.DB) *PostgresResponseReader {
+	return &PostgresResponseReader{db: db}
+}
+
+func (r *PostgresResponseReader) ListResponses(ctx context.Context, surveyId surveys.SurveyId) ([]surveys.SurveyResponse, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT data, version, created_at FROM survey_responses
+        WHERE data->>'surveyId' = $1
+        ORDER BY created_at, id
+    `, surveyId.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	responses := make([]surveys.SurveyResponse, 0)
+
+	for rows.Next() {
+		var data []byte
+		var version int
+		var createdAt time.Time
+
+		err = rows.Scan(&data, &version, &createdAt)
+		if err != nil {
+			return nil, err
+		}
+
+		var response surveys.SurveyResponse
+
+		err = json.Unmarshal(data, &response)
+		if err != nil {
+			return nil, err
+		}
+
+		response.SetVersion(version)
+		response.SetCreatedAt(createdAt)
+
+		responses = append(responses, response)
+	}
+
+	return responses, rows.Err()
+}
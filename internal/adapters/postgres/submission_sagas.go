@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+type PostgresSubmissionSagaReader struct {
+	db *sql.DB
+}
+
+func NewPostgresSubmissionSagaReader(db *sql.DB) *PostgresSubmissionSagaReader {
+	return &PostgresSubmissionSagaReader{db: db}
+}
+
+func (r *PostgresSubmissionSagaReader) PendingSubmissionSagas(ctx context.Context) ([]surveys.SurveyResponseId, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id FROM submission_sagas
+        WHERE data->>'status' = $1
+        ORDER BY created_at, id
+    `, string(surveys.SubmissionSagaStatusPending))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]surveys.SurveyResponseId, 0)
+
+	for rows.Next() {
+		var id surveys.SurveyResponseId
+
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
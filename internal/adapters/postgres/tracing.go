@@ -0,0 +1,87 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/markusryoti/survey-ddd/internal/adapters/postgres")
+
+// startStatement starts a span for a SQL statement. Spans are named by the
+// operation and table, e.g. "INSERT events", as the statement text can be
+// long.
+func startStatement(ctx context.Context, name string, query string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL, semconv.DBQueryText(query)),
+	)
+}
+
+// endStatement ends the span of a statement, marking it failed on errors
+// other than finding no rows.
+func endStatement(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (r *PostgresRepository) exec(ctx context.Context, name string, query string, args ...any) (sql.Result, error) {
+	ctx, span := startStatement(ctx, name, query)
+	res, err := r.tx.ExecContext(ctx, query, args...)
+	endStatement(span, err)
+
+	return res, err
+}
+
+func (r *PostgresRepository) queryRow(ctx context.Context, name string, query string, args ...any) *sql.Row {
+	ctx, span := startStatement(ctx, name, query)
+	row := r.tx.QueryRowContext(ctx, query, args...)
+	endStatement(span, row.Err())
+
+	return row
+}
+
+// query calls scan for each row of the query. The span lasts until the rows
+// have been read, as they're streamed from the database while scanning.
+func (r *PostgresRepository) query(ctx context.Context, name string, query string, scan func(rows *sql.Rows) error, args ...any) (err error) {
+	ctx, span := startStatement(ctx, name, query)
+	defer func() { endStatement(span, err) }()
+
+	rows, err := r.tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = scan(rows); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// traceContext returns the trace context of ctx as stored with outbox
+// messages, so a relay can put it in the message headers and consumers
+// continue the trace. It's NULL when there's no trace.
+func traceContext(ctx context.Context) (any, error) {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+
+	if len(carrier) == 0 {
+		return nil, nil
+	}
+
+	return json.Marshal(carrier)
+}
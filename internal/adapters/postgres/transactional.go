@@ -3,44 +3,226 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 
+	"github.com/lib/pq"
 	"github.com/markusryoti/survey-ddd/internal/core"
 )
 
+// serializationFailure is the SQLSTATE of transactions aborted because
+// they couldn't be serialized with concurrent ones. They succeed when run
+// again.
+const serializationFailure = "40001"
+
+type txKey struct{}
+
+// activeTx is a transaction carried in a context, with the isolation level
+// it was started with.
+type activeTx struct {
+	tx        *sql.Tx
+	isolation core.IsolationLevel
+	// savepoints numbers the savepoints of joining transactions.
+	savepoints int
+	// failure is a serialization failure a joining transaction ran into.
+	// The transaction can't commit after it, whatever fn returns.
+	failure error
+}
+
+func txFromContext(ctx context.Context) (*activeTx, bool) {
+	active, ok := ctx.Value(txKey{}).(*activeTx)
+	return active, ok
+}
+
+// PostgresTransactionalProvider runs transactions at the isolation level of
+// their context, see core.WithIsolationLevel. Transactions failing on a
+// serialization failure are run again, so transaction functions have to
+// load the aggregates they change like with core.RetryingTransactionProvider.
+// Serialization failures are returned as core.ErrSerializationFailure.
 type PostgresTransactionalProvider struct {
-	db *sql.DB
+	db     *sql.DB
+	events *core.EventRegistry
+	logger *slog.Logger
+	retry  core.RetryPolicy
 }
 
-func NewPostgresTransactionalProvider(db *sql.DB) *PostgresTransactionalProvider {
+func NewPostgresTransactionalProvider(db *sql.DB, events *core.EventRegistry, logger *slog.Logger) *PostgresTransactionalProvider {
 	return &PostgresTransactionalProvider{
-		db: db,
+		db:     db,
+		events: events,
+		logger: logger,
+		retry:  core.DefaultRetryPolicy(),
 	}
 }
 
 func (p *PostgresTransactionalProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
-	var err error
-	var tx *sql.Tx
+	if active, ok := txFromContext(ctx); ok {
+		return p.join(ctx, active, func() error {
+			return fn(NewPostgresRepository(active.tx, p.events, p.logger))
+		})
+	}
+
+	return core.Retry(ctx, p.retry, isSerializationFailure, func() error {
+		return p.run(ctx, core.IsolationLevelFromContext(ctx), func(tx *sql.Tx) error {
+			return fn(NewPostgresRepository(tx, p.events, p.logger))
+		})
+	})
+}
+
+// WithTransaction runs fn in a transaction carried in the context given to
+// fn. RunTransactional calls made with that context join the transaction
+// instead of starting their own, and it's committed only when fn succeeds.
+//
+// The transaction is serializable unless the context asks for another
+// level, as the level can't be raised for the calls joining it. When it
+// fails on a serialization failure, fn is run again in a new transaction,
+// so it must not have effects outside the transaction it can't undo.
+func (p *PostgresTransactionalProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if active, ok := txFromContext(ctx); ok {
+		return p.join(ctx, active, func() error {
+			return fn(ctx)
+		})
+	}
+
+	isolation := core.IsolationLevelFromContext(ctx)
+	if isolation == core.IsolationDefault {
+		isolation = core.IsolationSerializable
+	}
+
+	return core.Retry(ctx, p.retry, isSerializationFailure, func() error {
+		return p.run(ctx, isolation, func(tx *sql.Tx) error {
+			active := &activeTx{tx: tx, isolation: isolation}
+
+			err := fn(context.WithValue(ctx, txKey{}, active))
+			if active.failure != nil {
+				return active.failure
+			}
 
-	tx, err = p.db.BeginTx(ctx, &sql.TxOptions{})
+			return err
+		})
+	})
+}
+
+// join runs fn in a transaction that is already running. The isolation
+// level of a transaction can't be raised once it has started.
+//
+// fn runs in a savepoint, so its changes are undone when it fails and the
+// caller can go on with the transaction or run fn again, e.g. on a
+// concurrency conflict. Serialization failures abort the whole transaction
+// though: they are remembered, so the transaction is run again instead.
+func (p *PostgresTransactionalProvider) join(ctx context.Context, active *activeTx, fn func() error) (err error) {
+	if level := core.IsolationLevelFromContext(ctx); level > active.isolation {
+		return core.ErrIsolationConflict
+	}
+
+	active.savepoints++
+	savepoint := fmt.Sprintf("sp_%d", active.savepoints)
+
+	_, err = active.tx.ExecContext(ctx, "SAVEPOINT "+savepoint)
 	if err != nil {
-		return err
+		return serializationError(err)
 	}
 
+	released := false
+
 	defer func() {
-		if err != nil {
-			err = tx.Rollback()
+		if released {
+			return
+		}
+
+		_, rollbackErr := active.tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+savepoint)
+		if rollbackErr != nil {
+			p.logger.ErrorContext(ctx, "failed to roll back to savepoint", slog.String("error", rollbackErr.Error()))
+
 			if err != nil {
-				panic("rollback failed")
+				err = errors.Join(err, fmt.Errorf("rollback to savepoint failed: %w", rollbackErr))
 			}
 		}
 	}()
 
-	t := NewPostgresRepository(tx)
+	err = serializationError(fn())
+	if err != nil {
+		if isSerializationFailure(err) {
+			active.failure = err
+		}
+		return err
+	}
+
+	_, err = active.tx.ExecContext(ctx, "RELEASE SAVEPOINT "+savepoint)
+	if err != nil {
+		return serializationError(err)
+	}
+
+	released = true
+
+	return nil
+}
 
-	err = fn(t)
+// run runs fn in a new transaction, which is committed when fn succeeds and
+// rolled back when it fails or panics.
+func (p *PostgresTransactionalProvider) run(ctx context.Context, isolation core.IsolationLevel, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := p.db.BeginTx(ctx, &sql.TxOptions{Isolation: sqlIsolationLevel(isolation)})
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	committed := false
+
+	defer func() {
+		if committed {
+			return
+		}
+
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil && !errors.Is(rollbackErr, sql.ErrTxDone) {
+			p.logger.ErrorContext(ctx, "failed to roll back transaction", slog.String("error", rollbackErr.Error()))
+
+			if err != nil {
+				err = errors.Join(err, fmt.Errorf("rollback failed: %w", rollbackErr))
+			}
+		}
+	}()
+
+	err = fn(tx)
+	if err != nil {
+		return serializationError(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return serializationError(err)
+	}
+
+	committed = true
+
+	return nil
+}
+
+func sqlIsolationLevel(level core.IsolationLevel) sql.IsolationLevel {
+	switch level {
+	case core.IsolationReadCommitted:
+		return sql.LevelReadCommitted
+	case core.IsolationRepeatableRead:
+		return sql.LevelRepeatableRead
+	case core.IsolationSerializable:
+		return sql.LevelSerializable
+	default:
+		return sql.LevelDefault
+	}
+}
+
+func isSerializationFailure(err error) bool {
+	return errors.Is(err, core.ErrSerializationFailure)
+}
+
+// serializationError marks serialization failures of the database with
+// core.ErrSerializationFailure.
+func serializationError(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == serializationFailure && !isSerializationFailure(err) {
+		return fmt.Errorf("%w: %w", core.ErrSerializationFailure, err)
+	}
+
+	return err
 }
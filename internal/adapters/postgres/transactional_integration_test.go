@@ -0,0 +1,248 @@
+package postgres_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres/postgrestest"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionalProviderIntegration(t *testing.T) {
+	t.Run("rolls back when the transaction fails", func(t *testing.T) {
+		db := postgrestest.New(t)
+		tx := newProvider(t, db)
+		survey := newSurvey(t)
+
+		err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			err := repo.Save(context.Background(), survey)
+			if err != nil {
+				return err
+			}
+			return errors.New("failed")
+		})
+		assert.NotNil(t, err)
+
+		assertNotSaved(t, tx, survey.Id)
+		assert.Equal(t, 0, db.Stats().InUse)
+	})
+
+	t.Run("rolls back when the transaction panics", func(t *testing.T) {
+		db := postgrestest.New(t)
+		tx := newProvider(t, db)
+		survey := newSurvey(t)
+
+		assert.Panics(t, func() {
+			_ = tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+				_ = repo.Save(context.Background(), survey)
+				panic("boom")
+			})
+		})
+
+		assertNotSaved(t, tx, survey.Id)
+		assert.Equal(t, 0, db.Stats().InUse)
+	})
+
+	t.Run("retries serialization failures", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		var survey *surveys.Survey
+		calls := 0
+
+		err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+			calls++
+			survey = newSurvey(t)
+
+			err := repo.Save(context.Background(), survey)
+			if err != nil {
+				return err
+			}
+
+			if calls == 1 {
+				return &pq.Error{Code: "40001"}
+			}
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+
+		loadSurvey(t, tx, survey.Id)
+	})
+
+	t.Run("nested transactions join the one in the context", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+
+		err := tx.WithTransaction(context.Background(), func(ctx context.Context) error {
+			err := tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Save(ctx, survey)
+			})
+			if err != nil {
+				return err
+			}
+			return errors.New("failed")
+		})
+		assert.NotNil(t, err)
+
+		assertNotSaved(t, tx, survey.Id)
+	})
+
+	t.Run("failed nested transactions roll back to their savepoint", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		saved := newSurvey(t)
+		failed := newSurvey(t)
+
+		err := tx.WithTransaction(context.Background(), func(ctx context.Context) error {
+			err := tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Save(ctx, saved)
+			})
+			if err != nil {
+				return err
+			}
+
+			err = tx.RunTransactional(ctx, func(repo core.Repository) error {
+				err := repo.Save(ctx, failed)
+				if err != nil {
+					return err
+				}
+				return errors.New("failed")
+			})
+			assert.NotNil(t, err)
+
+			return nil
+		})
+		assert.Nil(t, err)
+
+		loadSurvey(t, tx, saved.Id)
+		assertNotSaved(t, tx, failed.Id)
+	})
+
+	t.Run("retries context transactions on serialization failures", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+		save(t, tx, survey)
+		id := core.AggregateId(survey.Id)
+		calls := 0
+
+		err := tx.WithTransaction(context.Background(), func(ctx context.Context) error {
+			calls++
+			loaded := new(surveys.Survey)
+
+			err := tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Load(ctx, id, loaded)
+			})
+			if err != nil {
+				return err
+			}
+
+			// A concurrent transaction changes the survey after it was
+			// read, so saving it can't be serialized.
+			if calls == 1 {
+				err = core.Update(context.Background(), tx, id, func(s *surveys.Survey) error {
+					return s.SetMaxParticipants(10)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			err = loaded.SetMaxParticipants(loaded.MaxParticipants + 5)
+			if err != nil {
+				return err
+			}
+
+			return tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Save(ctx, loaded)
+			})
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+
+		assert.Equal(t, 15, loadSurvey(t, tx, survey.Id).MaxParticipants)
+	})
+
+	t.Run("retries context transactions when fn ignores a serialization failure", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+		save(t, tx, survey)
+		id := core.AggregateId(survey.Id)
+		calls := 0
+
+		err := tx.WithTransaction(context.Background(), func(ctx context.Context) error {
+			calls++
+			loaded := new(surveys.Survey)
+
+			err := tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Load(ctx, id, loaded)
+			})
+			if err != nil {
+				return err
+			}
+
+			if calls == 1 {
+				err = core.Update(context.Background(), tx, id, func(s *surveys.Survey) error {
+					return s.SetMaxParticipants(10)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			err = loaded.SetMaxParticipants(loaded.MaxParticipants + 5)
+			if err != nil {
+				return err
+			}
+
+			// The failed save is rolled back to its savepoint, so only
+			// the remembered failure keeps the transaction from committing
+			// without it.
+			_ = tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Save(ctx, loaded)
+			})
+
+			return nil
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 2, calls)
+
+		assert.Equal(t, 15, loadSurvey(t, tx, survey.Id).MaxParticipants)
+	})
+
+	t.Run("nested transactions can't raise the isolation level", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		ctx := core.WithIsolationLevel(context.Background(), core.IsolationReadCommitted)
+
+		err := tx.WithTransaction(ctx, func(ctx context.Context) error {
+			ctx = core.WithIsolationLevel(ctx, core.IsolationSerializable)
+
+			return tx.RunTransactional(ctx, func(repo core.Repository) error {
+				return nil
+			})
+		})
+		assert.ErrorIs(t, err, core.ErrIsolationConflict)
+	})
+
+	t.Run("runs serializable transactions", func(t *testing.T) {
+		tx := newProvider(t, postgrestest.New(t))
+		survey := newSurvey(t)
+
+		ctx := core.WithIsolationLevel(context.Background(), core.IsolationSerializable)
+		err := tx.RunTransactional(ctx, func(repo core.Repository) error {
+			return repo.Save(ctx, survey)
+		})
+		assert.Nil(t, err)
+
+		loadSurvey(t, tx, survey.Id)
+	})
+}
+
+func assertNotSaved(t *testing.T, tx core.TransactionProvider, id surveys.SurveyId) {
+	err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Load(context.Background(), core.AggregateId(id), new(surveys.Survey))
+	})
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+}
@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+)
+
+const (
+	userIdHeader     = "X-User-Id"
+	tenantIdHeader   = "X-Tenant-Id"
+	userRolesHeader  = "X-User-Roles"
+	gatewayKeyHeader = "X-Gateway-Key"
+)
+
+// RequireGatewayKey rejects requests that don't carry one of the keys of
+// the authenticating gateway, so identity headers can't be forged by
+// clients reaching the API directly.
+func RequireGatewayKey(keys []string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			presented := []byte(r.Header.Get(gatewayKeyHeader))
+
+			for _, key := range keys {
+				if subtle.ConstantTimeCompare(presented, []byte(key)) == 1 {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			writeErrorResponse(w, http.StatusUnauthorized, "invalid gateway key")
+		})
+	}
+}
+
+// Authenticate reads the identity forwarded by the authenticating gateway
+// and stores it in the request context. Requests without an identity are
+// passed through, handlers reject them when authorization is required.
+func Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userId := r.Header.Get(userIdHeader)
+		if userId == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		user := auth.User{
+			Id:       userId,
+			TenantId: r.Header.Get(tenantIdHeader),
+		}
+
+		for _, value := range strings.Split(r.Header.Get(userRolesHeader), ",") {
+			role, err := auth.NewRole(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+			user.Roles = append(user.Roles, role)
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+	})
+}
@@ -0,0 +1,33 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireGatewayKey(t *testing.T) {
+	handler := rest.RequireGatewayKey([]string{"old", "new"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for key, status := range map[string]int{
+		"old":   http.StatusNoContent,
+		"new":   http.StatusNoContent,
+		"other": http.StatusUnauthorized,
+		"":      http.StatusUnauthorized,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if key != "" {
+			req.Header.Set("X-Gateway-Key", key)
+		}
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, status, res.Code, key)
+	}
+}
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const defaultCheckTimeout = 2 * time.Second
+
+// HealthCheck checks a dependency the service needs to serve traffic.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// HealthHandler serves the liveness and readiness endpoints.
+type HealthHandler struct {
+	Checks []HealthCheck
+	// Timeout bounds each check. Zero uses a default.
+	Timeout time.Duration
+}
+
+func (h HealthHandler) RegisterRoutes(r chi.Router) {
+	r.Get("/healthz", h.Healthz)
+	r.Get("/readyz", h.Readyz)
+}
+
+type HealthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Healthz reports that the process is alive. It doesn't check
+// dependencies, so an unavailable database doesn't get the process
+// restarted.
+func (h HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, HealthResponse{Status: "ok"})
+}
+
+// Readyz runs every check concurrently and reports whether the service can
+// take traffic, with the result of each check.
+func (h HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	res := HealthResponse{
+		Status: "ok",
+		Checks: make(map[string]CheckResult, len(h.Checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range h.Checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+
+			result := CheckResult{Status: "ok"}
+			if err := check.Check(ctx); err != nil {
+				result = CheckResult{Status: "unavailable", Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			res.Checks[check.Name] = result
+			if result.Error != "" {
+				res.Status = "unavailable"
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	status := http.StatusOK
+	if res.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeHealth(w, status, res)
+}
+
+func writeHealth(w http.ResponseWriter, status int, res HealthResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// DialCheck checks that a TCP connection can be opened to the address.
+func DialCheck(address string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var d net.Dialer
+
+		conn, err := d.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return err
+		}
+
+		return conn.Close()
+	}
+}
@@ -0,0 +1,84 @@
+package rest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	t.Run("liveness doesn't depend on checks", func(t *testing.T) {
+		res := serveHealth(rest.HealthHandler{Checks: []rest.HealthCheck{failingCheck("database")}}, "/healthz")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+	})
+
+	t.Run("ready when every check passes", func(t *testing.T) {
+		res := serveHealth(rest.HealthHandler{Checks: []rest.HealthCheck{passingCheck("database"), passingCheck("outbox")}}, "/readyz")
+
+		assert.Equal(t, http.StatusOK, res.Code)
+		assert.Equal(t, rest.HealthResponse{
+			Status: "ok",
+			Checks: map[string]rest.CheckResult{
+				"database": {Status: "ok"},
+				"outbox":   {Status: "ok"},
+			},
+		}, decodeHealth(t, res))
+	})
+
+	t.Run("not ready when a check fails", func(t *testing.T) {
+		res := serveHealth(rest.HealthHandler{Checks: []rest.HealthCheck{passingCheck("database"), failingCheck("outbox")}}, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+		assert.Equal(t, rest.HealthResponse{
+			Status: "unavailable",
+			Checks: map[string]rest.CheckResult{
+				"database": {Status: "ok"},
+				"outbox":   {Status: "unavailable", Error: "boom"},
+			},
+		}, decodeHealth(t, res))
+	})
+
+	t.Run("checks time out", func(t *testing.T) {
+		slow := rest.HealthCheck{Name: "broker", Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		res := serveHealth(rest.HealthHandler{Checks: []rest.HealthCheck{slow}, Timeout: time.Millisecond}, "/readyz")
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.Code)
+	})
+}
+
+func serveHealth(handler rest.HealthHandler, path string) *httptest.ResponseRecorder {
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+
+	return res
+}
+
+func decodeHealth(t *testing.T, res *httptest.ResponseRecorder) rest.HealthResponse {
+	var health rest.HealthResponse
+	assert.Nil(t, json.NewDecoder(res.Body).Decode(&health))
+	return health
+}
+
+func passingCheck(name string) rest.HealthCheck {
+	return rest.HealthCheck{Name: name, Check: func(ctx context.Context) error { return nil }}
+}
+
+func failingCheck(name string) rest.HealthCheck {
+	return rest.HealthCheck{Name: name, Check: func(ctx context.Context) error { return errors.New("boom") }}
+}
@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+type HistoryEventView struct {
+	Type       string          `json:"type"`
+	Version    int             `json:"version"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Metadata   core.Metadata   `json:"metadata"`
+	Payload    json.RawMessage `json:"payload"`
+}
+
+type HistoryResponse struct {
+	Events []HistoryEventView `json:"events"`
+	// NextAfter is the value of the after parameter for the next page, or
+	// null if there are no more events.
+	NextAfter *int `json:"nextAfter"`
+}
+
+// GetSurveyHistory returns the events of a survey ordered by version. Pages
+// are requested with the after and limit query parameters.
+func (h SurveyHandler) GetSurveyHistory(w http.ResponseWriter, r *http.Request) {
+	after, err := intParam(r, "after")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid after parameter")
+		return
+	}
+
+	limit, err := intParam(r, "limit")
+	if err != nil {
+		writeErrorResponse(w, http.StatusBadRequest, "invalid limit parameter")
+		return
+	}
+
+	history, err := h.QueryHandler.GetSurveyHistory(r.Context(), chi.URLParam(r, "id"), after, limit)
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusInternalServerError), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	res := HistoryResponse{
+		Events: make([]HistoryEventView, 0, len(history.Events)),
+	}
+
+	for _, e := range history.Events {
+		res.Events = append(res.Events, HistoryEventView{
+			Type:       e.Type,
+			Version:    e.Version,
+			OccurredAt: e.OccurredAt,
+			Metadata:   e.Metadata,
+			Payload:    e.Payload,
+		})
+	}
+
+	if history.HasMore {
+		next := history.Events[len(history.Events)-1].Version
+		res.NextAfter = &next
+	}
+
+	_ = h.writeJson(w, res)
+}
+
+// intParam reads an optional non-negative integer query parameter.
+func intParam(r *http.Request, name string) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, strconv.ErrSyntax
+	}
+
+	return n, nil
+}
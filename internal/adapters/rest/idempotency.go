@@ -0,0 +1,191 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/ports"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	maxIdempotentRequestBytes = 1 << 20
+)
+
+// errRequestFailed rolls back the transaction of a request that didn't
+// succeed, which also releases its idempotency key for another attempt.
+var errRequestFailed = errors.New("request failed")
+
+// Idempotency makes POST requests carrying an Idempotency-Key header safe to
+// retry. The key is reserved in the same transaction the command runs in,
+// and only successful responses are stored, so a key is either unused or
+// tied to exactly one committed command.
+type Idempotency struct {
+	tx        core.ContextTransactionProvider
+	store     ports.IdempotencyStore
+	retention time.Duration
+	logger    *slog.Logger
+}
+
+func NewIdempotency(
+	tx core.ContextTransactionProvider,
+	store ports.IdempotencyStore,
+	retention time.Duration,
+	logger *slog.Logger,
+) *Idempotency {
+	return &Idempotency{
+		tx:        tx,
+		store:     store,
+		retention: retention,
+		logger:    logger,
+	}
+}
+
+func (i *Idempotency) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if r.Method != http.MethodPost || key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			writeErrorResponse(w, http.StatusBadRequest, "idempotency key is too long")
+			return
+		}
+
+		// The body is buffered to hash it and run the request again, so
+		// larger ones are rejected rather than cut short.
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeErrorResponse(w, http.StatusRequestEntityTooLarge, "request body is too large")
+			return
+		}
+		if err != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid request body")
+			return
+		}
+
+		// Keys are scoped to the caller so clients can't collide with or
+		// replay each other's requests.
+		user, _ := auth.UserFromContext(r.Context())
+		scopedKey := user.TenantId + ":" + user.Id + ":" + key
+		hash := requestHash(r, body)
+
+		var rec *responseRecorder
+		var existing *ports.IdempotencyRecord
+
+		// The transaction is run again on serialization failures, so every
+		// run starts with the buffered request and a fresh response.
+		err = i.tx.WithTransaction(r.Context(), func(ctx context.Context) error {
+			rec = newResponseRecorder()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			existing, err = i.store.Reserve(ctx, scopedKey, hash, time.Now().Add(-i.retention))
+			if err != nil || existing != nil {
+				return err
+			}
+
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			if rec.status >= http.StatusMultipleChoices {
+				return errRequestFailed
+			}
+
+			return i.store.Complete(ctx, ports.IdempotencyRecord{
+				Key:         scopedKey,
+				RequestHash: hash,
+				StatusCode:  rec.status,
+				ContentType: rec.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+			})
+		})
+
+		switch {
+		case errors.Is(err, errRequestFailed):
+			rec.writeTo(w)
+		case errors.Is(err, core.ErrSerializationFailure):
+			writeErrorResponse(w, http.StatusConflict, "request conflicted with a concurrent one, try again")
+		case err != nil:
+			recordError(r, i.logger, err.Error())
+			writeErrorResponse(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+		case existing != nil && existing.RequestHash != hash:
+			writeErrorResponse(w, http.StatusConflict, "idempotency key has already been used for a different request")
+		case existing != nil:
+			if existing.ContentType != "" {
+				w.Header().Set("Content-Type", existing.ContentType)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(existing.StatusCode)
+			_, _ = w.Write(existing.Body)
+		default:
+			rec.writeTo(w)
+		}
+	})
+}
+
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder buffers a response so it can be stored before it's
+// sent, or discarded together with the transaction.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		header: make(http.Header),
+	}
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) writeTo(w http.ResponseWriter) {
+	for k, v := range r.header {
+		w.Header()[k] = v
+	}
+
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+
+	w.WriteHeader(r.status)
+	_, _ = w.Write(r.body.Bytes())
+}
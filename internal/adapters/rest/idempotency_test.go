@@ -0,0 +1,203 @@
+package rest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/logging"
+	"github.com/markusryoti/survey-ddd/internal/ports"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotency(t *testing.T) {
+	t.Run("replays the stored response for a retried request", func(t *testing.T) {
+		handler, calls := newIdempotentHandler(http.StatusCreated)
+
+		first := post(handler, "key", `{"title":"a"}`)
+		second := post(handler, "key", `{"title":"a"}`)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("rejects reusing a key for a different request", func(t *testing.T) {
+		handler, calls := newIdempotentHandler(http.StatusCreated)
+
+		_ = post(handler, "key", `{"title":"a"}`)
+		res := post(handler, "key", `{"title":"b"}`)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+
+	t.Run("failed request releases the key", func(t *testing.T) {
+		handler, calls := newIdempotentHandler(http.StatusBadRequest)
+
+		first := post(handler, "key", `{"title":"a"}`)
+		second := post(handler, "key", `{"title":"a"}`)
+
+		assert.Equal(t, 2, *calls)
+		assert.Equal(t, http.StatusBadRequest, first.Code)
+		assert.Equal(t, http.StatusBadRequest, second.Code)
+		assert.Empty(t, second.Header().Get("Idempotent-Replayed"))
+	})
+
+	t.Run("rejects bodies too large to buffer", func(t *testing.T) {
+		handler, calls := newIdempotentHandler(http.StatusCreated)
+
+		res := post(handler, "key", `{"title":"`+strings.Repeat("a", 1<<20)+`"}`)
+
+		assert.Equal(t, 0, *calls)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.Code)
+	})
+
+	t.Run("doesn't expose store errors", func(t *testing.T) {
+		store := newMemoryIdempotencyStore()
+		store.err = errors.New("connection refused to db.internal:5432")
+		idempotency := rest.NewIdempotency(&memoryTransactionProvider{store: store}, store, time.Hour, logging.New(io.Discard, "json", slog.LevelError))
+
+		handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+		}))
+
+		res := post(handler, "key", `{"title":"a"}`)
+
+		assert.Equal(t, http.StatusInternalServerError, res.Code)
+		assert.NotContains(t, res.Body.String(), "db.internal")
+	})
+
+	t.Run("requests without a key are not deduplicated", func(t *testing.T) {
+		handler, calls := newIdempotentHandler(http.StatusCreated)
+
+		_ = post(handler, "", `{"title":"a"}`)
+		_ = post(handler, "", `{"title":"a"}`)
+
+		assert.Equal(t, 2, *calls)
+	})
+}
+
+func newIdempotentHandler(status int) (http.Handler, *int) {
+	store := newMemoryIdempotencyStore()
+	idempotency := rest.NewIdempotency(&memoryTransactionProvider{store: store}, store, time.Hour, logging.New(io.Discard, "json", slog.LevelError))
+
+	calls := 0
+
+	handler := idempotency.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `}`))
+	}))
+
+	return handler, &calls
+}
+
+func post(handler http.Handler, key string, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/surveys", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+
+	res := httptest.NewRecorder()
+	handler.ServeHTTP(res, req)
+
+	return res
+}
+
+// memoryIdempotencyStore fails reservations with err when it's set.
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]ports.IdempotencyRecord
+	err     error
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{
+		records: make(map[string]ports.IdempotencyRecord),
+	}
+}
+
+func (s *memoryIdempotencyStore) Reserve(ctx context.Context, key string, requestHash string, expiredBefore time.Time) (*ports.IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	if record, ok := s.records[key]; ok && !record.CreatedAt.Before(expiredBefore) {
+		return &record, nil
+	}
+
+	s.records[key] = ports.IdempotencyRecord{
+		Key:         key,
+		RequestHash: requestHash,
+		CreatedAt:   time.Now(),
+	}
+
+	return nil, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, record ports.IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record.CreatedAt = s.records[record.Key].CreatedAt
+	s.records[record.Key] = record
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key, record := range s.records {
+		if record.CreatedAt.Before(before) {
+			delete(s.records, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// memoryTransactionProvider discards the changes made to the store when the
+// transaction fails.
+type memoryTransactionProvider struct {
+	store *memoryIdempotencyStore
+}
+
+func (p *memoryTransactionProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	return fn(nil)
+}
+
+func (p *memoryTransactionProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	p.store.mu.Lock()
+	snapshot := maps.Clone(p.store.records)
+	p.store.mu.Unlock()
+
+	err := fn(ctx)
+	if err != nil {
+		p.store.mu.Lock()
+		p.store.records = snapshot
+		p.store.mu.Unlock()
+	}
+
+	return err
+}
@@ -0,0 +1,113 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+type CreateInvitationsRequest struct {
+	Count     int       `json:"count"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type IssuedInvitationResponse struct {
+	Id        string    `json:"id"`
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (h SurveyHandler) CreateInvitations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req CreateInvitationsRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	issued, err := core.Dispatch[[]surveys.IssuedInvitation](r.Context(), h.Commands, surveys.CreateInvitationsCommand{
+		SurveyId:  id,
+		Count:     req.Count,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	invitations := make([]IssuedInvitationResponse, 0, len(issued))
+	for _, i := range issued {
+		invitations = append(invitations, IssuedInvitationResponse{
+			Id:        i.Id.String(),
+			Token:     i.Token,
+			ExpiresAt: i.ExpiresAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = h.writeJson(w, map[string]any{
+		"invitations": invitations,
+	})
+}
+
+type InvitationResponse struct {
+	Id         string     `json:"id"`
+	Status     string     `json:"status"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	RedeemedAt *time.Time `json:"redeemedAt,omitempty"`
+	ResponseId *string    `json:"responseId,omitempty"`
+}
+
+func (h SurveyHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	invitations, err := h.QueryHandler.ListInvitations(r.Context(), id)
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusInternalServerError), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	now := time.Now()
+
+	res := make([]InvitationResponse, 0, len(invitations))
+	for _, i := range invitations {
+		item := InvitationResponse{
+			Id:        i.Id.String(),
+			Status:    string(i.Status(now)),
+			ExpiresAt: i.ExpiresAt,
+		}
+
+		// Invitations to anonymous surveys don't record their response.
+		if i.InvitationStatus == surveys.InvitationStatusRedeemed && i.Linked() {
+			responseId := i.ResponseId.String()
+			item.RedeemedAt = &i.RedeemedAt
+			item.ResponseId = &responseId
+		}
+
+		res = append(res, item)
+	}
+
+	_ = h.writeJson(w, map[string]any{
+		"invitations": res,
+	})
+}
+
+func (h SurveyHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	_, err := h.Commands.Dispatch(r.Context(), surveys.RevokeInvitationCommand{
+		SurveyId:     chi.URLParam(r, "id"),
+		InvitationId: chi.URLParam(r, "invitationId"),
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,79 @@
+package rest
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestLogger logs every request once it has been handled, together with
+// the error recorded for a failed one. It must run after RequestMetadata so
+// the log lines carry the request ids.
+func RequestLogger(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			rec := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			var failure string
+			r = r.WithContext(context.WithValue(r.Context(), requestErrorKey{}, &failure))
+
+			next.ServeHTTP(rec, r)
+
+			status := rec.Status()
+			if status == 0 {
+				// Nothing was written, which net/http answers with 200 OK.
+				status = http.StatusOK
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", routePattern(r)),
+				slog.Int("status", status),
+				slog.Duration("duration", time.Since(start)),
+			}
+			if failure != "" {
+				attrs = append(attrs, slog.String("error", failure))
+			}
+
+			logger.LogAttrs(r.Context(), level, "request handled", attrs...)
+		})
+	}
+}
+
+type requestErrorKey struct{}
+
+// recordError attaches the error of a failed request to the line written by
+// RequestLogger, so each failure is logged once. Without RequestLogger the
+// error is logged right away.
+func recordError(r *http.Request, logger *slog.Logger, message string) {
+	if failure, ok := r.Context().Value(requestErrorKey{}).(*string); ok {
+		*failure = message
+		return
+	}
+
+	logger.ErrorContext(r.Context(), "request failed",
+		slog.String("route", routePattern(r)),
+		slog.String("error", message),
+	)
+}
+
+// routePattern returns the pattern of the route matched by the request, or
+// an empty string outside chi routing.
+func routePattern(r *http.Request) string {
+	rctx := chi.RouteContext(r.Context())
+	if rctx == nil {
+		return ""
+	}
+
+	return rctx.RoutePattern()
+}
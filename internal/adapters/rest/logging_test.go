@@ -0,0 +1,66 @@
+package rest_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/markusryoti/survey-ddd/internal/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+
+	r := chi.NewRouter()
+	r.Use(rest.RequestMetadata)
+	r.Use(rest.RequestLogger(logging.New(&buf, "json", slog.LevelInfo)))
+	r.Get("/surveys/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/surveys/123", nil)
+	req.Header.Set("X-Request-Id", "request")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	var line map[string]any
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, "/surveys/{id}", line["route"])
+	assert.Equal(t, float64(http.StatusInternalServerError), line["status"])
+	assert.Equal(t, "request", line["requestId"])
+}
+
+func TestRequestLoggerLogsFailuresOnce(t *testing.T) {
+	var buf bytes.Buffer
+	logger := logging.New(&buf, "json", slog.LevelInfo)
+
+	store := newMemoryIdempotencyStore()
+	store.err = errors.New("connection refused")
+	idempotency := rest.NewIdempotency(&memoryTransactionProvider{store: store}, store, time.Hour, logger)
+
+	r := chi.NewRouter()
+	r.Use(rest.RequestLogger(logger))
+	r.Use(idempotency.Middleware)
+	r.Post("/surveys", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	res := post(r, "key", `{"title":"a"}`)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.Len(t, lines, 1)
+
+	var line map[string]any
+	assert.Nil(t, json.Unmarshal(lines[0], &line))
+	assert.Equal(t, "ERROR", line["level"])
+	assert.Equal(t, "connection refused", line["error"])
+}
@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+const (
+	requestIdHeader     = "X-Request-Id"
+	correlationIdHeader = "X-Correlation-Id"
+)
+
+// RequestMetadata stores the request and correlation ids of the request in
+// its context, so they end up in the metadata of the events it produces.
+// Ids not sent by the client are generated, and both are echoed back in the
+// response headers.
+func RequestMetadata(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestId := r.Header.Get(requestIdHeader)
+		if requestId == "" {
+			requestId = uuid.NewString()
+		}
+
+		// A request without a correlation id starts a new conversation.
+		correlationId := r.Header.Get(correlationIdHeader)
+		if correlationId == "" {
+			correlationId = requestId
+		}
+
+		w.Header().Set(requestIdHeader, requestId)
+		w.Header().Set(correlationIdHeader, correlationId)
+
+		ctx := core.WithMetadata(r.Context(), core.Metadata{
+			CorrelationId: correlationId,
+			CausationId:   requestId,
+			RequestId:     requestId,
+		})
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,49 @@
+package rest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestMetadata(t *testing.T) {
+	var metadata core.Metadata
+	handler := rest.RequestMetadata(rest.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadata = core.MetadataFromContext(r.Context())
+	})))
+
+	t.Run("uses the ids sent by the client", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/surveys", nil)
+		req.Header.Set("X-Request-Id", "request")
+		req.Header.Set("X-Correlation-Id", "correlation")
+		req.Header.Set("X-User-Id", "user")
+		req.Header.Set("X-Tenant-Id", "tenant")
+
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, req)
+
+		assert.Equal(t, core.Metadata{
+			ActorId:       "user",
+			TenantId:      "tenant",
+			CorrelationId: "correlation",
+			CausationId:   "request",
+			RequestId:     "request",
+		}, metadata)
+		assert.Equal(t, "request", res.Header().Get("X-Request-Id"))
+		assert.Equal(t, "correlation", res.Header().Get("X-Correlation-Id"))
+	})
+
+	t.Run("generates missing ids", func(t *testing.T) {
+		res := httptest.NewRecorder()
+		handler.ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.NotEmpty(t, metadata.RequestId)
+		assert.Equal(t, metadata.RequestId, metadata.CorrelationId)
+		assert.Equal(t, metadata.RequestId, res.Header().Get("X-Request-Id"))
+		assert.Empty(t, metadata.ActorId)
+	})
+}
@@ -0,0 +1,67 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/application/service"
+)
+
+type StartResponseRequest struct {
+	InvitationToken string `json:"invitationToken"`
+}
+
+// StartResponse starts a response to the survey and reserves a participant
+// slot for it. The body is optional for respondents that need no invitation.
+func (h SurveyHandler) StartResponse(w http.ResponseWriter, r *http.Request) {
+	var req StartResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	responseId, err := h.Responses.StartResponse(r.Context(), service.StartResponseCmd{
+		SurveyId:        chi.URLParam(r, "id"),
+		InvitationToken: req.InvitationToken,
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = h.writeJson(w, map[string]string{
+		"responseId": responseId.String(),
+	})
+}
+
+type SubmitResponseRequest struct {
+	InvitationToken string `json:"invitationToken"`
+}
+
+// SubmitResponse submits a started response. Respondents of pseudonymous
+// surveys identify themselves with the invitation token they started it
+// with, other respondents can leave the body out.
+func (h SurveyHandler) SubmitResponse(w http.ResponseWriter, r *http.Request) {
+	var req SubmitResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	err := h.Responses.SubmitResponse(r.Context(), service.SubmitResponseCmd{
+		SurveyId:        chi.URLParam(r, "id"),
+		ResponseId:      chi.URLParam(r, "responseId"),
+		InvitationToken: req.InvitationToken,
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
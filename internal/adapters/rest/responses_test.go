@@ -0,0 +1,224 @@
+package rest_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/application/service"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/markusryoti/survey-ddd/internal/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponses(t *testing.T) {
+	t.Run("submissions run serializably", func(t *testing.T) {
+		tx := &isolationRecordingProvider{}
+		r := newResponseRouter(tx)
+
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/surveys/"+uuid.NewString()+"/responses/"+uuid.NewString()+"/submit", nil))
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Equal(t, core.IsolationSerializable, tx.isolation)
+	})
+
+	t.Run("started responses run at the default level", func(t *testing.T) {
+		tx := &isolationRecordingProvider{}
+		r := newResponseRouter(tx)
+
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/surveys/"+uuid.NewString()+"/responses", nil))
+
+		assert.Equal(t, http.StatusNotFound, res.Code)
+		assert.Equal(t, core.IsolationDefault, tx.isolation)
+	})
+
+	t.Run("started responses are returned as JSON", func(t *testing.T) {
+		survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+		_ = survey.SetEndTime(time.Now().Add(time.Hour))
+		_ = survey.SetMaxParticipants(3)
+		_ = survey.Release(time.Now())
+
+		tx := newMemoryAggregateProvider(survey)
+		r := newResponseRouter(tx)
+
+		req := httptest.NewRequest(http.MethodPost, "/surveys/"+survey.Id.String()+"/responses", nil)
+		req.Header.Set("X-User-Id", "respondent")
+		req.Header.Set("X-Tenant-Id", "tenant")
+		req.Header.Set("X-User-Roles", "respondent")
+		res := httptest.NewRecorder()
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, http.StatusCreated, res.Code)
+		assert.Equal(t, "application/json", res.Result().Header.Get("Content-Type"))
+		assert.Contains(t, res.Body.String(), "responseId")
+	})
+
+	t.Run("idempotent submissions are run again on serialization failures", func(t *testing.T) {
+		tx := newSerializationFailingProvider(1)
+		r := newIdempotentResponseRouter(tx)
+
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/surveys/"+uuid.NewString()+"/responses/"+uuid.NewString()+"/submit", nil)
+		req.Header.Set("Idempotency-Key", "key")
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, 2, tx.runs)
+		assert.Equal(t, http.StatusNotFound, res.Code)
+	})
+
+	t.Run("idempotent submissions conflict when serialization keeps failing", func(t *testing.T) {
+		tx := newSerializationFailingProvider(10)
+		r := newIdempotentResponseRouter(tx)
+
+		res := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/surveys/"+uuid.NewString()+"/responses/"+uuid.NewString()+"/submit", nil)
+		req.Header.Set("Idempotency-Key", "key")
+		r.ServeHTTP(res, req)
+
+		assert.Equal(t, 3, tx.runs)
+		assert.Equal(t, http.StatusConflict, res.Code)
+	})
+}
+
+func newIdempotentResponseRouter(tx *serializationFailingProvider) http.Handler {
+	handler := rest.SurveyHandler{
+		Responses:   service.NewSurveyService(tx, auth.NewRolePolicy()),
+		Idempotency: rest.NewIdempotency(tx, tx.store, time.Hour, logging.New(io.Discard, "json", slog.LevelError)),
+		Logger:      logging.New(io.Discard, "json", slog.LevelError),
+	}
+
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	return r
+}
+
+func newResponseRouter(tx core.TransactionProvider) http.Handler {
+	handler := rest.SurveyHandler{
+		Responses: service.NewSurveyService(tx, auth.NewRolePolicy()),
+		Logger:    logging.New(io.Discard, "json", slog.LevelError),
+	}
+
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	return r
+}
+
+// isolationRecordingProvider records the isolation level transactions are
+// started with and fails them as if the aggregate didn't exist.
+type isolationRecordingProvider struct {
+	isolation core.IsolationLevel
+}
+
+func (p *isolationRecordingProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	p.isolation = core.IsolationLevelFromContext(ctx)
+	return sql.ErrNoRows
+}
+
+// serializationFailingProvider fails its first transactions on a
+// serialization failure, and runs context transactions again when one of
+// the transactions joining them failed, like the Postgres provider. The
+// aggregates don't exist once the failures run out.
+type serializationFailingProvider struct {
+	memoryTransactionProvider
+	failures int
+	failed   bool
+	runs     int
+}
+
+func newSerializationFailingProvider(failures int) *serializationFailingProvider {
+	return &serializationFailingProvider{
+		memoryTransactionProvider: memoryTransactionProvider{store: newMemoryIdempotencyStore()},
+		failures:                  failures,
+	}
+}
+
+func (p *serializationFailingProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	if p.failures > 0 {
+		p.failures--
+		p.failed = true
+		return core.ErrSerializationFailure
+	}
+
+	return sql.ErrNoRows
+}
+
+func (p *serializationFailingProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	policy := core.RetryPolicy{MaxAttempts: 3}
+
+	return core.Retry(ctx, policy, func(err error) bool {
+		return errors.Is(err, core.ErrSerializationFailure)
+	}, func() error {
+		p.runs++
+		p.failed = false
+
+		return p.memoryTransactionProvider.WithTransaction(ctx, func(ctx context.Context) error {
+			err := fn(ctx)
+			if err != nil && p.failed {
+				return core.ErrSerializationFailure
+			}
+
+			return err
+		})
+	})
+}
+
+// memoryAggregateProvider keeps aggregates as JSON, like their rows.
+type memoryAggregateProvider struct {
+	rows map[core.AggregateId][]byte
+}
+
+func newMemoryAggregateProvider(aggregates ...core.Aggregate) *memoryAggregateProvider {
+	p := &memoryAggregateProvider{rows: make(map[core.AggregateId][]byte)}
+	for _, aggregate := range aggregates {
+		_ = p.Save(context.Background(), aggregate)
+	}
+
+	return p
+}
+
+func (p *memoryAggregateProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	return fn(p)
+}
+
+func (p *memoryAggregateProvider) Save(ctx context.Context, aggregate core.Aggregate) error {
+	data, err := json.Marshal(aggregate)
+	if err != nil {
+		return err
+	}
+
+	p.rows[aggregate.ID()] = data
+	return nil
+}
+
+func (p *memoryAggregateProvider) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	data, ok := p.rows[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	return json.Unmarshal(data, aggregate)
+}
+
+func (p *memoryAggregateProvider) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return core.ErrNotEventSourced
+}
+
+func (p *memoryAggregateProvider) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return core.ErrNotEventSourced
+}
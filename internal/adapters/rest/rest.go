@@ -1,25 +1,66 @@
 package rest
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-chi/chi/v5"
 
-	"github.com/markusryoti/survey-ddd/internal/application/command"
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/application/query"
+	"github.com/markusryoti/survey-ddd/internal/application/service"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
 )
 
 type SurveyHandler struct {
-	CommandHandler *command.CommandHandler
-	QueryHandler   *query.QueryHandler
+	Commands     *core.CommandBus
+	QueryHandler *query.QueryHandler
+	// Responses serves the response routes when set.
+	Responses   *service.SurveyService
+	Idempotency *Idempotency
+	// GatewayKeys are required from callers when set.
+	GatewayKeys []string
+	Logger      *slog.Logger
 }
 
 func (h SurveyHandler) RegisterRoutes(r chi.Router) {
+	r.Use(RequestMetadata)
+	r.Use(RequestLogger(h.Logger))
+
+	if len(h.GatewayKeys) > 0 {
+		r.Use(RequireGatewayKey(h.GatewayKeys))
+	}
+
+	r.Use(Authenticate)
+
+	if h.Idempotency != nil {
+		r.Use(h.Idempotency.Middleware)
+	}
+
 	r.Get("/", h.index)
 	r.Post("/surveys", h.CreateSurvey)
 	r.Get("/surveys/{id}", h.GetSurvey)
+	r.Get("/surveys/{id}/history", h.GetSurveyHistory)
+	r.Get("/surveys/{id}/results", h.GetSurveyResults)
+	r.Get("/surveys/{id}/responses", h.ListResponses)
 	r.Post("/surveys/{id}/questions", h.AddQuestion)
+	r.Post("/surveys/{id}/release", h.ReleaseSurvey)
+	r.Put("/surveys/{id}/anonymity-mode", h.SetAnonymityMode)
+	r.Put("/surveys/{id}/invitation-required", h.SetInvitationRequired)
+	r.Post("/surveys/{id}/collaborators", h.AddCollaborator)
+	r.Delete("/surveys/{id}/collaborators/{userId}", h.RemoveCollaborator)
+	r.Post("/surveys/{id}/invitations", h.CreateInvitations)
+	r.Get("/surveys/{id}/invitations", h.ListInvitations)
+	r.Delete("/surveys/{id}/invitations/{invitationId}", h.RevokeInvitation)
+
+	if h.Responses != nil {
+		r.Post("/surveys/{id}/responses", h.StartResponse)
+		r.Post("/surveys/{id}/responses/{responseId}/submit", h.SubmitResponse)
+	}
 }
 
 func (h SurveyHandler) index(w http.ResponseWriter, r *http.Request) {
@@ -39,8 +80,39 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
-func (h SurveyHandler) writeError(w http.ResponseWriter, status int, err ErrorResponse) {
-	w.WriteHeader(status)
+// writeError writes the error response. Server errors are recorded for the
+// request log, since the client can't do anything about them, and their
+// details are replaced with the status text so internals don't leak.
+func (h SurveyHandler) writeError(w http.ResponseWriter, r *http.Request, status int, err ErrorResponse) {
+	if status >= http.StatusInternalServerError {
+		recordError(r, h.Logger, err.Message)
+		err.Message = http.StatusText(status)
+	}
+
+	writeErrorResponse(w, status, err.Message)
+}
+
+func writeErrorResponse(w http.ResponseWriter, status int, message string) {
 	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(err)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(ErrorResponse{Message: message})
+}
+
+// errorStatus maps application errors to a status code, falling back to the
+// given one for errors without a specific mapping.
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, auth.ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, auth.ErrForbidden), errors.Is(err, surveys.ErrInvitationRequired):
+		return http.StatusForbidden
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, surveys.ErrNoSlotsAvailable), errors.Is(err, surveys.ErrAlreadyResponded):
+		return http.StatusConflict
+	case errors.Is(err, core.ErrSerializationFailure):
+		return http.StatusConflict
+	default:
+		return fallback
+	}
 }
@@ -0,0 +1,42 @@
+package rest_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/adapters/rest"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/markusryoti/survey-ddd/internal/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerErrors(t *testing.T) {
+	var buf bytes.Buffer
+
+	bus := core.NewCommandBus()
+	core.Handle(bus, func(ctx context.Context, cmd surveys.CreateSurveyCommand) (*surveys.Survey, error) {
+		return nil, errors.New("dial tcp 10.0.0.5:5432: connection refused")
+	})
+
+	handler := rest.SurveyHandler{
+		Commands: bus,
+		Logger:   logging.New(&buf, "json", slog.LevelInfo),
+	}
+	r := chi.NewRouter()
+	handler.RegisterRoutes(r)
+
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, httptest.NewRequest(http.MethodPost, "/surveys", strings.NewReader(`{"title":"a","tenantId":"tenant"}`)))
+
+	assert.Equal(t, http.StatusInternalServerError, res.Code)
+	assert.JSONEq(t, `{"message":"Internal Server Error"}`, res.Body.String())
+	assert.Contains(t, buf.String(), "connection refused")
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type SurveyResultsView struct {
+	SurveyId  string                `json:"surveyId"`
+	Submitted int                   `json:"submitted"`
+	Questions []QuestionResultsView `json:"questions"`
+}
+
+type QuestionResultsView struct {
+	QuestionId string `json:"questionId"`
+	Answered   int    `json:"answered"`
+	// Choices is the number of times each option was chosen, by option id.
+	Choices map[string]int `json:"choices"`
+}
+
+// GetSurveyResults returns the answers to a survey counted over its
+// submitted responses.
+func (h SurveyHandler) GetSurveyResults(w http.ResponseWriter, r *http.Request) {
+	results, err := h.QueryHandler.GetSurveyResults(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusInternalServerError), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	res := SurveyResultsView{
+		SurveyId:  results.SurveyId.String(),
+		Submitted: results.Submitted,
+		Questions: make([]QuestionResultsView, 0, len(results.Questions)),
+	}
+
+	for _, q := range results.Questions {
+		question := QuestionResultsView{
+			QuestionId: string(q.QuestionId),
+			Answered:   q.Answered,
+			Choices:    make(map[string]int, len(q.Choices)),
+		}
+
+		for option, count := range q.Choices {
+			question.Choices[string(option)] = count
+		}
+
+		res.Questions = append(res.Questions, question)
+	}
+
+	_ = h.writeJson(w, res)
+}
+
+type ResponseView struct {
+	Id           string               `json:"id"`
+	RespondentId string               `json:"respondentId,omitempty"`
+	Status       string               `json:"status"`
+	Answers      []ResponseAnswerView `json:"answers"`
+	CreatedAt    time.Time            `json:"createdAt"`
+}
+
+type ResponseAnswerView struct {
+	QuestionId string   `json:"questionId"`
+	Choices    []string `json:"choices"`
+}
+
+// ListResponses returns the individual responses to a survey.
+func (h SurveyHandler) ListResponses(w http.ResponseWriter, r *http.Request) {
+	responses, err := h.QueryHandler.ListResponses(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusInternalServerError), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	res := make([]ResponseView, 0, len(responses))
+	for _, response := range responses {
+		view := ResponseView{
+			Id:           response.Id.String(),
+			RespondentId: string(response.RespondentId),
+			Status:       string(response.Status),
+			Answers:      make([]ResponseAnswerView, 0, len(response.Responses)),
+			CreatedAt:    response.CreatedAt(),
+		}
+
+		for _, answer := range response.Responses {
+			choices := make([]string, 0, len(answer.Choices))
+			for _, choice := range answer.Choices {
+				choices = append(choices, string(choice))
+			}
+
+			view.Answers = append(view.Answers, ResponseAnswerView{
+				QuestionId: string(answer.QuestionId),
+				Choices:    choices,
+			})
+		}
+
+		res = append(res, view)
+	}
+
+	_ = h.writeJson(w, map[string]any{
+		"responses": res,
+	})
+}
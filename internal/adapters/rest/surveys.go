@@ -3,8 +3,11 @@ package rest
 import (
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/core"
 	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
 )
 
@@ -31,9 +34,9 @@ func (h SurveyHandler) CreateSurvey(w http.ResponseWriter, r *http.Request) {
 		TenantId:    req.TenantId,
 	}
 
-	survey, err := h.CommandHandler.CreateSurvey(r.Context(), cmd)
+	survey, err := core.Dispatch[*surveys.Survey](r.Context(), h.Commands, cmd)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, errorStatus(err, http.StatusInternalServerError), ErrorResponse{Message: err.Error()})
 		return
 	}
 
@@ -49,15 +52,45 @@ type CreateSurveyRequest struct {
 	TenantId    string  `json:"tenantId"`
 }
 
+// GetSurvey returns the current survey, or a past state of it when the
+// version or asOf query parameter is given.
 func (h SurveyHandler) GetSurvey(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	var survey surveys.Survey
+	var err error
+
+	switch {
+	case query.Has("version") && query.Has("asOf"):
+		writeErrorResponse(w, http.StatusBadRequest, "version and asOf can't be combined")
+		return
+	case query.Has("version"):
+		version, convErr := strconv.Atoi(query.Get("version"))
+		if convErr != nil || version < 1 {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid version parameter")
+			return
+		}
+
+		survey, err = h.QueryHandler.GetSurveyAt(r.Context(), id, version)
+	case query.Has("asOf"):
+		asOf, parseErr := time.Parse(time.RFC3339, query.Get("asOf"))
+		if parseErr != nil {
+			writeErrorResponse(w, http.StatusBadRequest, "invalid asOf parameter")
+			return
+		}
+
+		survey, err = h.QueryHandler.GetSurveyAsOf(r.Context(), id, asOf)
+	default:
+		survey, err = h.QueryHandler.GetSurvey(r.Context(), id)
+	}
 
-	survey, err := h.QueryHandler.GetSurvey(r.Context(), id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		h.writeError(w, r, errorStatus(err, http.StatusInternalServerError), ErrorResponse{Message: err.Error()})
+		return
 	}
 
-	_ = h.writeJson(w, survey)
+	_ = h.writeJson(w, NewSurveyView(survey))
 }
 
 type AddQuestionRequest struct {
@@ -77,7 +110,7 @@ func (h SurveyHandler) AddQuestion(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.CommandHandler.AddQuestion(r.Context(), surveys.AddQuestionCommand{
+	_, err := h.Commands.Dispatch(r.Context(), surveys.AddQuestionCommand{
 		SurveyId:        id,
 		Title:           req.Title,
 		Description:     req.Description,
@@ -86,13 +119,118 @@ func (h SurveyHandler) AddQuestion(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		h.writeError(w, http.StatusBadRequest, ErrorResponse{Message: err.Error()})
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
 		return
 	}
 
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (h SurveyHandler) ReleaseSurvey(w http.ResponseWriter, r *http.Request) {
+	_, err := h.Commands.Dispatch(r.Context(), surveys.ReleaseSurveyCommand{
+		SurveyId: chi.URLParam(r, "id"),
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type SetAnonymityModeRequest struct {
+	AnonymityMode string `json:"anonymityMode"`
+}
+
+func (h SurveyHandler) SetAnonymityMode(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req SetAnonymityModeRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.Commands.Dispatch(r.Context(), surveys.SetAnonymityModeCommand{
+		SurveyId:      id,
+		AnonymityMode: req.AnonymityMode,
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type SetInvitationRequiredRequest struct {
+	InvitationRequired bool `json:"invitationRequired"`
+}
+
+func (h SurveyHandler) SetInvitationRequired(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req SetInvitationRequiredRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.Commands.Dispatch(r.Context(), surveys.SetInvitationRequiredCommand{
+		SurveyId:           id,
+		InvitationRequired: req.InvitationRequired,
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type AddCollaboratorRequest struct {
+	UserId     string `json:"userId"`
+	Permission string `json:"permission"`
+}
+
+func (h SurveyHandler) AddCollaborator(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req AddCollaboratorRequest
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	_, err := h.Commands.Dispatch(r.Context(), surveys.AddCollaboratorCommand{
+		SurveyId:   id,
+		UserId:     req.UserId,
+		Permission: req.Permission,
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h SurveyHandler) RemoveCollaborator(w http.ResponseWriter, r *http.Request) {
+	_, err := h.Commands.Dispatch(r.Context(), surveys.RemoveCollaboratorCommand{
+		SurveyId: chi.URLParam(r, "id"),
+		UserId:   chi.URLParam(r, "userId"),
+	})
+	if err != nil {
+		h.writeError(w, r, errorStatus(err, http.StatusBadRequest), ErrorResponse{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 type QuestionInput struct {
 	Text         string                `json:"text"`
 	QuestionType string                `json:"question_type"`
@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+// SurveyView is the representation of a survey returned by the API. It's
+// mapped from the domain explicitly so changes to the aggregate don't leak
+// into the API.
+type SurveyView struct {
+	Id                 string             `json:"id"`
+	Title              string             `json:"title"`
+	Description        *string            `json:"description"`
+	Status             string             `json:"status"`
+	TenantId           string             `json:"tenantId"`
+	OwnerId            string             `json:"ownerId"`
+	AnonymityMode      string             `json:"anonymityMode"`
+	InvitationRequired bool               `json:"invitationRequired"`
+	MaxParticipants    int                `json:"maxParticipants"`
+	EndTime            *time.Time         `json:"endTime"`
+	AnswersReceived    int                `json:"answersReceived"`
+	Questions          []QuestionView     `json:"questions"`
+	Collaborators      []CollaboratorView `json:"collaborators"`
+	Version            int                `json:"version"`
+	CreatedAt          time.Time          `json:"createdAt"`
+}
+
+type QuestionView struct {
+	Id           string               `json:"id"`
+	Title        string               `json:"title"`
+	Description  *string              `json:"description"`
+	QuestionType string               `json:"questionType"`
+	Options      []QuestionOptionView `json:"options"`
+}
+
+type QuestionOptionView struct {
+	Id    string `json:"id"`
+	Value string `json:"value"`
+}
+
+type CollaboratorView struct {
+	UserId     string `json:"userId"`
+	Permission string `json:"permission"`
+}
+
+func NewSurveyView(survey surveys.Survey) SurveyView {
+	view := SurveyView{
+		Id:                 survey.Id.String(),
+		Title:              survey.Title,
+		Description:        survey.Description,
+		Status:             string(survey.Status()),
+		TenantId:           survey.TenantId,
+		OwnerId:            survey.OwnerId,
+		AnonymityMode:      string(survey.AnonymityMode),
+		InvitationRequired: survey.InvitationRequired,
+		MaxParticipants:    survey.MaxParticipants,
+		AnswersReceived:    survey.AnswersReceived(),
+		Questions:          make([]QuestionView, 0, len(survey.Questions)),
+		Collaborators:      make([]CollaboratorView, 0, len(survey.Collaborators)),
+		Version:            survey.Version(),
+		CreatedAt:          survey.CreatedAt(),
+	}
+
+	if !survey.EndTime.IsZero() {
+		view.EndTime = &survey.EndTime
+	}
+
+	for _, q := range survey.Questions {
+		question := QuestionView{
+			Id:           string(q.Id),
+			Title:        q.Title,
+			Description:  q.Description,
+			QuestionType: string(q.QuestionType),
+			Options:      make([]QuestionOptionView, 0, len(q.QuestionOptions)),
+		}
+
+		for _, o := range q.QuestionOptions {
+			question.Options = append(question.Options, QuestionOptionView{
+				Id:    string(o.Id),
+				Value: o.Value,
+			})
+		}
+
+		view.Questions = append(view.Questions, question)
+	}
+
+	for _, c := range survey.Collaborators {
+		view.Collaborators = append(view.Collaborators, CollaboratorView{
+			UserId:     c.UserId,
+			Permission: string(c.Permission),
+		})
+	}
+
+	return view
+}
@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+// TransactionProvider starts a span for each transaction. The statements
+// run in it are traced as its children.
+type TransactionProvider struct {
+	next    core.TransactionProvider
+	tracing *Tracing
+}
+
+func (t *Tracing) TransactionProvider(next core.TransactionProvider) *TransactionProvider {
+	return &TransactionProvider{next: next, tracing: t}
+}
+
+func (p *TransactionProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	ctx, span := p.tracing.tracer.Start(ctx, "RunTransactional")
+
+	err := p.next.RunTransactional(ctx, func(repo core.Repository) error {
+		return fn(&repository{Repository: repo, span: span})
+	})
+	end(span, err)
+
+	return err
+}
+
+func (p *TransactionProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	next, ok := p.next.(core.ContextTransactionProvider)
+	if !ok {
+		return core.ErrContextTransactionsUnsupported
+	}
+
+	ctx, span := p.tracing.tracer.Start(ctx, "WithTransaction")
+
+	err := next.WithTransaction(ctx, fn)
+	end(span, err)
+
+	return err
+}
+
+// repository makes the transaction span the parent of the statements run
+// through it. fn calls the repository with its own context, which doesn't
+// carry the span.
+type repository struct {
+	core.Repository
+	span trace.Span
+}
+
+func (r *repository) Save(ctx context.Context, aggregate core.Aggregate) error {
+	return r.Repository.Save(trace.ContextWithSpan(ctx, r.span), aggregate)
+}
+
+func (r *repository) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	return r.Repository.Load(trace.ContextWithSpan(ctx, r.span), id, aggregate)
+}
+
+func (r *repository) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return r.Repository.LoadAt(trace.ContextWithSpan(ctx, r.span), id, version, aggregate)
+}
+
+func (r *repository) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return r.Repository.LoadAsOf(trace.ContextWithSpan(ctx, r.span), id, asOf, aggregate)
+}
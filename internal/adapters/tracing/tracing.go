@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	sdkresource "go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/markusryoti/survey-ddd/internal/adapters/tracing"
+
+// Tracing records OpenTelemetry spans of HTTP requests, commands and
+// transactions. Queries and SQL statements are traced by their packages
+// with the global tracer provider, see Install.
+type Tracing struct {
+	provider *sdktrace.TracerProvider
+	tracer   trace.Tracer
+}
+
+// New returns tracing exporting spans of the service in batches to
+// exporter.
+func New(serviceName string, exporter sdktrace.SpanExporter) *Tracing {
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(sdkresource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	)
+
+	return &Tracing{
+		provider: provider,
+		tracer:   provider.Tracer(instrumentationName),
+	}
+}
+
+// NewExporter returns the exporter by name: stdout writes spans as JSON
+// to w, none drops them.
+func NewExporter(name string, w io.Writer) (sdktrace.SpanExporter, error) {
+	switch name {
+	case "stdout":
+		return stdouttrace.New(stdouttrace.WithWriter(w))
+	case "none":
+		return discardExporter{}, nil
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", name)
+	}
+}
+
+// Install makes the tracer provider and the W3C trace context propagator
+// global.
+func (t *Tracing) Install() {
+	otel.SetTracerProvider(t.provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// Shutdown exports the buffered spans and stops the exporter.
+func (t *Tracing) Shutdown(ctx context.Context) error {
+	return t.provider.Shutdown(ctx)
+}
+
+// Middleware starts a span for each HTTP request, continuing the trace of
+// the caller when the request carries trace context. Spans are named with
+// the route pattern rather than the path, like the request metrics.
+func (t *Tracing) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+		ctx, span := t.tracer.Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(semconv.HTTPRequestMethodKey.String(r.Method), semconv.URLPath(r.URL.Path)),
+		)
+		defer span.End()
+
+		rec := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		status := rec.Status()
+		if status == 0 {
+			// Nothing was written, which net/http answers with 200 OK.
+			status = http.StatusOK
+		}
+
+		if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+			span.SetName(r.Method + " " + rctx.RoutePattern())
+			span.SetAttributes(semconv.HTTPRoute(rctx.RoutePattern()))
+		}
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
+
+// ObserveCommand implements core.CommandObserver.
+func (t *Tracing) ObserveCommand(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, "command "+name)
+
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+// ObserveQuery implements core.QueryObserver.
+func (t *Tracing) ObserveQuery(ctx context.Context, name string) (context.Context, func(err error)) {
+	ctx, span := t.tracer.Start(ctx, "query "+name)
+
+	return ctx, func(err error) {
+		end(span, err)
+	}
+}
+
+// end ends the span, marking it failed when err is set.
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type discardExporter struct{}
+
+func (discardExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	return nil
+}
+
+func (discardExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
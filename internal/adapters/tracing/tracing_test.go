@@ -0,0 +1,149 @@
+package tracing_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/markusryoti/survey-ddd/internal/adapters/tracing"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracing(t *testing.T) {
+	t.Run("continues the trace of the caller", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tr := tracing.New("test", exporter)
+		tr.Install()
+
+		r := chi.NewRouter()
+		r.Use(tr.Middleware)
+		r.Get("/surveys/{id}", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/surveys/123", nil)
+		req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+		r.ServeHTTP(httptest.NewRecorder(), req)
+
+		spans := exporter.flush(t, tr)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "GET /surveys/{id}", spans[0].Name())
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+		assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	})
+
+	t.Run("records failed commands", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tr := tracing.New("test", exporter)
+
+		bus := core.NewCommandBus(core.ObserverMiddleware(tr))
+		core.HandleFunc(bus, func(ctx context.Context, cmd pingCommand) error {
+			return errors.New("failed")
+		})
+
+		_, _ = bus.Dispatch(context.Background(), pingCommand{})
+
+		spans := exporter.flush(t, tr)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "command ping", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	})
+
+	t.Run("records failed queries", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tr := tracing.New("test", exporter)
+
+		_, done := tr.ObserveQuery(context.Background(), "GetSurvey")
+		done(errors.New("failed"))
+
+		spans := exporter.flush(t, tr)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "query GetSurvey", spans[0].Name())
+		assert.Equal(t, codes.Error, spans[0].Status().Code)
+	})
+
+	t.Run("statements are children of the transaction", func(t *testing.T) {
+		exporter := &recordingExporter{}
+		tr := tracing.New("test", exporter)
+		repo := &spanRecordingRepository{}
+
+		ctx := context.Background()
+		_ = tr.TransactionProvider(repo).RunTransactional(ctx, func(r core.Repository) error {
+			return r.Load(ctx, core.NewAggregateId(), nil)
+		})
+
+		spans := exporter.flush(t, tr)
+		assert.Len(t, spans, 1)
+		assert.Equal(t, "RunTransactional", spans[0].Name())
+		assert.Equal(t, spans[0].SpanContext().SpanID(), repo.parent.SpanID())
+	})
+}
+
+type pingCommand struct{}
+
+func (pingCommand) CommandName() string { return "ping" }
+
+// recordingExporter keeps the exported spans. The in-memory exporter of
+// the SDK drops them on shutdown, which is what flushes them.
+type recordingExporter struct {
+	mu    sync.Mutex
+	spans []sdktrace.ReadOnlySpan
+}
+
+func (e *recordingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *recordingExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+func (e *recordingExporter) flush(t *testing.T, tr *tracing.Tracing) []sdktrace.ReadOnlySpan {
+	assert.Nil(t, tr.Shutdown(context.Background()))
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.spans
+}
+
+// spanRecordingRepository records the span its statements would run in.
+type spanRecordingRepository struct {
+	parent trace.SpanContext
+}
+
+func (r *spanRecordingRepository) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	return fn(r)
+}
+
+func (r *spanRecordingRepository) Save(ctx context.Context, aggregate core.Aggregate) error {
+	r.parent = trace.SpanContextFromContext(ctx)
+	return nil
+}
+
+func (r *spanRecordingRepository) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	r.parent = trace.SpanContextFromContext(ctx)
+	return nil
+}
+
+func (r *spanRecordingRepository) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return nil
+}
+
+func (r *spanRecordingRepository) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return nil
+}
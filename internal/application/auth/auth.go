@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+var (
+	ErrUnauthenticated = errors.New("unauthenticated")
+	ErrForbidden       = errors.New("forbidden")
+)
+
+type Role string
+
+const (
+	RoleTenantAdmin  Role = "tenant-admin"
+	RoleSurveyAuthor Role = "survey-author"
+	RoleAnalyst      Role = "analyst"
+	RoleRespondent   Role = "respondent"
+)
+
+func NewRole(role string) (Role, error) {
+	switch r := Role(role); r {
+	case RoleTenantAdmin, RoleSurveyAuthor, RoleAnalyst, RoleRespondent:
+		return r, nil
+	default:
+		return "", fmt.Errorf("invalid role: %s", role)
+	}
+}
+
+// User is the authenticated caller. Authentication itself happens at the
+// edge, the application only consumes the resulting identity.
+type User struct {
+	Id       string
+	TenantId string
+	Roles    []Role
+}
+
+func (u User) HasRole(role Role) bool {
+	return slices.Contains(u.Roles, role)
+}
+
+type userKey struct{}
+
+// WithUser stores the user in the context and records it as the actor of
+// the changes made with it.
+func WithUser(ctx context.Context, user User) context.Context {
+	ctx = core.WithMetadata(ctx, core.Metadata{
+		ActorId:  user.Id,
+		TenantId: user.TenantId,
+	})
+
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(userKey{}).(User)
+	return user, ok
+}
+
+// AuthenticationMiddleware rejects commands dispatched without an
+// authenticated user. Access to individual resources is still checked by
+// the command handlers once the resource has been loaded.
+func AuthenticationMiddleware() core.CommandMiddleware {
+	return func(next core.CommandHandlerFunc) core.CommandHandlerFunc {
+		return func(ctx context.Context, cmd core.Command) (any, error) {
+			if _, ok := UserFromContext(ctx); !ok {
+				return nil, ErrUnauthenticated
+			}
+
+			return next(ctx, cmd)
+		}
+	}
+}
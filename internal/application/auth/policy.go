@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+type Action string
+
+const (
+	ActionCreateSurvey        Action = "create-survey"
+	ActionEditSurvey          Action = "edit-survey"
+	ActionManageCollaborators Action = "manage-collaborators"
+	ActionViewSurvey          Action = "view-survey"
+	ActionViewResults         Action = "view-results"
+	ActionViewResponses       Action = "view-responses"
+	ActionViewHistory         Action = "view-history"
+	ActionRespond             Action = "respond"
+)
+
+// Resource describes the thing an action is performed on.
+type Resource struct {
+	TenantId      string
+	OwnerId       string
+	Editors       []string
+	ResultViewers []string
+}
+
+func SurveyResource(survey surveys.Survey) Resource {
+	resource := Resource{
+		TenantId: survey.TenantId,
+		OwnerId:  survey.OwnerId,
+	}
+
+	for _, c := range survey.Collaborators {
+		switch c.Permission {
+		case surveys.PermissionEdit:
+			resource.Editors = append(resource.Editors, c.UserId)
+		case surveys.PermissionViewResults:
+			resource.ResultViewers = append(resource.ResultViewers, c.UserId)
+		}
+	}
+
+	return resource
+}
+
+// Relation is how a user is related to a single resource.
+type Relation string
+
+const (
+	RelationNone         Relation = ""
+	RelationOwner        Relation = "owner"
+	RelationEditor       Relation = "editor"
+	RelationResultViewer Relation = "result-viewer"
+)
+
+func (r Resource) RelationOf(userId string) Relation {
+	switch {
+	case r.OwnerId != "" && r.OwnerId == userId:
+		return RelationOwner
+	case slices.Contains(r.Editors, userId):
+		return RelationEditor
+	case slices.Contains(r.ResultViewers, userId):
+		return RelationResultViewer
+	default:
+		return RelationNone
+	}
+}
+
+type Policy interface {
+	Authorize(user User, action Action, resource Resource) error
+}
+
+// RolePolicy grants actions based on the tenant-wide roles of the user and
+// on the relation of the user to the resource. Users never get access to
+// resources of another tenant.
+type RolePolicy struct {
+	roleGrants     map[Role][]Action
+	relationGrants map[Relation][]Action
+}
+
+func NewRolePolicy() *RolePolicy {
+	return &RolePolicy{
+		roleGrants: map[Role][]Action{
+			RoleTenantAdmin: {
+				ActionCreateSurvey,
+				ActionEditSurvey,
+				ActionManageCollaborators,
+				ActionViewSurvey,
+				ActionViewResults,
+				ActionViewResponses,
+				ActionViewHistory,
+			},
+			RoleSurveyAuthor: {
+				ActionCreateSurvey,
+				ActionViewSurvey,
+			},
+			RoleAnalyst: {
+				ActionViewSurvey,
+				ActionViewResults,
+			},
+			RoleRespondent: {
+				ActionViewSurvey,
+				ActionRespond,
+			},
+		},
+		relationGrants: map[Relation][]Action{
+			RelationOwner: {
+				ActionEditSurvey,
+				ActionManageCollaborators,
+				ActionViewSurvey,
+				ActionViewResults,
+				ActionViewResponses,
+				ActionViewHistory,
+			},
+			RelationEditor: {
+				ActionEditSurvey,
+				ActionViewSurvey,
+				ActionViewResults,
+				ActionViewResponses,
+				ActionViewHistory,
+			},
+			RelationResultViewer: {
+				ActionViewSurvey,
+				ActionViewResults,
+			},
+		},
+	}
+}
+
+func (p *RolePolicy) Authorize(user User, action Action, resource Resource) error {
+	if user.Id == "" {
+		return ErrUnauthenticated
+	}
+
+	if user.TenantId != resource.TenantId {
+		return fmt.Errorf("%w: user %s does not belong to tenant %s", ErrForbidden, user.Id, resource.TenantId)
+	}
+
+	for _, role := range user.Roles {
+		if slices.Contains(p.roleGrants[role], action) {
+			return nil
+		}
+	}
+
+	if slices.Contains(p.relationGrants[resource.RelationOf(user.Id)], action) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: user %s is not allowed to %s", ErrForbidden, user.Id, action)
+}
+
+// Authorize checks the action against the user stored in the context.
+func Authorize(ctx context.Context, policy Policy, action Action, resource Resource) error {
+	user, ok := UserFromContext(ctx)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	return policy.Authorize(user, action, resource)
+}
@@ -0,0 +1,144 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRolePolicy(t *testing.T) {
+	policy := auth.NewRolePolicy()
+	resource := auth.Resource{TenantId: "tenant", OwnerId: "owner"}
+
+	matrix := map[auth.Role]map[auth.Action]bool{
+		auth.RoleTenantAdmin: {
+			auth.ActionCreateSurvey:        true,
+			auth.ActionEditSurvey:          true,
+			auth.ActionManageCollaborators: true,
+			auth.ActionViewSurvey:          true,
+			auth.ActionViewResults:         true,
+			auth.ActionViewResponses:       true,
+			auth.ActionViewHistory:         true,
+			auth.ActionRespond:             false,
+		},
+		auth.RoleSurveyAuthor: {
+			auth.ActionCreateSurvey:        true,
+			auth.ActionEditSurvey:          false,
+			auth.ActionManageCollaborators: false,
+			auth.ActionViewSurvey:          true,
+			auth.ActionViewResults:         false,
+			auth.ActionViewResponses:       false,
+			auth.ActionViewHistory:         false,
+			auth.ActionRespond:             false,
+		},
+		auth.RoleAnalyst: {
+			auth.ActionCreateSurvey:        false,
+			auth.ActionEditSurvey:          false,
+			auth.ActionManageCollaborators: false,
+			auth.ActionViewSurvey:          true,
+			auth.ActionViewResults:         true,
+			auth.ActionViewResponses:       false,
+			auth.ActionViewHistory:         false,
+			auth.ActionRespond:             false,
+		},
+		auth.RoleRespondent: {
+			auth.ActionCreateSurvey:        false,
+			auth.ActionEditSurvey:          false,
+			auth.ActionManageCollaborators: false,
+			auth.ActionViewSurvey:          true,
+			auth.ActionViewResults:         false,
+			auth.ActionViewResponses:       false,
+			auth.ActionViewHistory:         false,
+			auth.ActionRespond:             true,
+		},
+	}
+
+	for role, actions := range matrix {
+		for action, allowed := range actions {
+			t.Run(string(role)+" "+string(action), func(t *testing.T) {
+				user := auth.User{Id: "user", TenantId: "tenant", Roles: []auth.Role{role}}
+
+				err := policy.Authorize(user, action, resource)
+				if allowed {
+					assert.Nil(t, err)
+				} else {
+					assert.ErrorIs(t, err, auth.ErrForbidden)
+				}
+			})
+		}
+	}
+
+	relations := map[string]map[auth.Action]bool{
+		"owner": {
+			auth.ActionEditSurvey:          true,
+			auth.ActionManageCollaborators: true,
+			auth.ActionViewResults:         true,
+			auth.ActionViewResponses:       true,
+			auth.ActionViewHistory:         true,
+		},
+		"editor": {
+			auth.ActionEditSurvey:          true,
+			auth.ActionManageCollaborators: false,
+			auth.ActionViewResults:         true,
+			auth.ActionViewResponses:       true,
+			auth.ActionViewHistory:         true,
+		},
+		"viewer": {
+			auth.ActionEditSurvey:          false,
+			auth.ActionManageCollaborators: false,
+			auth.ActionViewResults:         true,
+			auth.ActionViewResponses:       false,
+			auth.ActionViewHistory:         false,
+		},
+	}
+
+	shared := auth.Resource{
+		TenantId:      "tenant",
+		OwnerId:       "owner",
+		Editors:       []string{"editor"},
+		ResultViewers: []string{"viewer"},
+	}
+
+	for userId, actions := range relations {
+		for action, allowed := range actions {
+			t.Run(userId+" "+string(action), func(t *testing.T) {
+				user := auth.User{Id: userId, TenantId: "tenant", Roles: []auth.Role{auth.RoleSurveyAuthor}}
+
+				err := policy.Authorize(user, action, shared)
+				if allowed {
+					assert.Nil(t, err)
+				} else {
+					assert.ErrorIs(t, err, auth.ErrForbidden)
+				}
+			})
+		}
+	}
+
+	t.Run("owner can't access survey after moving to another tenant", func(t *testing.T) {
+		user := auth.User{Id: "owner", TenantId: "other", Roles: []auth.Role{auth.RoleSurveyAuthor}}
+
+		err := policy.Authorize(user, auth.ActionEditSurvey, shared)
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("admin can't access another tenant", func(t *testing.T) {
+		user := auth.User{Id: "admin", TenantId: "other", Roles: []auth.Role{auth.RoleTenantAdmin}}
+
+		err := policy.Authorize(user, auth.ActionViewSurvey, resource)
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("user without roles is forbidden", func(t *testing.T) {
+		user := auth.User{Id: "user", TenantId: "tenant"}
+
+		err := policy.Authorize(user, auth.ActionViewSurvey, resource)
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("missing user is unauthenticated", func(t *testing.T) {
+		err := auth.Authorize(context.Background(), policy, auth.ActionViewSurvey, resource)
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	})
+}
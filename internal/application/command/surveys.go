@@ -2,32 +2,64 @@ package command
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/core"
 	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
 )
 
 type CommandHandler struct {
-	tx core.TransactionProvider
+	tx     core.TransactionProvider
+	policy auth.Policy
+	logger *slog.Logger
 }
 
 func NewCommandHandler(
 	txProvider core.TransactionProvider,
+	policy auth.Policy,
+	logger *slog.Logger,
 ) *CommandHandler {
 	return &CommandHandler{
-		tx: txProvider,
+		tx:     txProvider,
+		policy: policy,
+		logger: logger,
 	}
 }
 
+// Register registers the handlers of all survey commands on the bus.
+func (h *CommandHandler) Register(bus *core.CommandBus) {
+	core.Handle(bus, h.CreateSurvey)
+	core.HandleFunc(bus, h.SetMaxParticipants)
+	core.HandleFunc(bus, h.SetAnonymityMode)
+	core.HandleFunc(bus, h.SetInvitationRequired)
+	core.HandleFunc(bus, h.ReleaseSurvey)
+	core.HandleFunc(bus, h.AddQuestion)
+	core.HandleFunc(bus, h.AddCollaborator)
+	core.HandleFunc(bus, h.RemoveCollaborator)
+	core.Handle(bus, h.CreateInvitations)
+	core.HandleFunc(bus, h.RevokeInvitation)
+}
+
 func (h *CommandHandler) CreateSurvey(ctx context.Context, cmd surveys.CreateSurveyCommand) (*surveys.Survey, error) {
 	var err error
 
+	err = auth.Authorize(ctx, h.policy, auth.ActionCreateSurvey, auth.Resource{TenantId: cmd.TenantId})
+	if err != nil {
+		h.logDenied(ctx, auth.ActionCreateSurvey, err, slog.String("tenantId", cmd.TenantId))
+		return nil, err
+	}
+
+	user, _ := auth.UserFromContext(ctx)
+
 	survey := new(surveys.Survey)
 
 	err = h.tx.RunTransactional(ctx, func(repo core.Repository) error {
 		defer survey.ClearUncommittedEvents()
 
-		survey, err = surveys.NewSurvey(cmd.Title, cmd.Description, cmd.TenantId)
+		survey, err = surveys.NewSurvey(cmd.Title, cmd.Description, cmd.TenantId, user.Id)
 		if err != nil {
 			return err
 		}
@@ -39,8 +71,13 @@ func (h *CommandHandler) CreateSurvey(ctx context.Context, cmd surveys.CreateSur
 
 		return err
 	})
+	if err != nil {
+		return survey, err
+	}
 
-	return survey, err
+	h.logger.InfoContext(ctx, "survey created", slog.String("surveyId", survey.Id.String()))
+
+	return survey, nil
 }
 
 func (h *CommandHandler) SetMaxParticipants(ctx context.Context, cmd surveys.SetMaxParticipantsCommand) error {
@@ -49,27 +86,67 @@ func (h *CommandHandler) SetMaxParticipants(ctx context.Context, cmd surveys.Set
 		return err
 	}
 
-	return h.tx.RunTransactional(ctx, func(repo core.Repository) error {
-		survey := new(surveys.Survey)
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
+		if err != nil {
+			return err
+		}
 
-		defer survey.ClearUncommittedEvents()
+		return survey.SetMaxParticipants(cmd.MaxParticipants)
+	})
+}
+
+func (h *CommandHandler) SetAnonymityMode(ctx context.Context, cmd surveys.SetAnonymityModeCommand) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	mode, err := surveys.NewAnonymityMode(cmd.AnonymityMode)
+	if err != nil {
+		return err
+	}
 
-		err = repo.Load(ctx, core.AggregateId(surveyId), survey)
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
 		if err != nil {
 			return err
 		}
 
-		err = survey.SetMaxParticipants(cmd.MaxParticipants)
+		return survey.SetAnonymityMode(mode)
+	})
+}
+
+func (h *CommandHandler) SetInvitationRequired(ctx context.Context, cmd surveys.SetInvitationRequiredCommand) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
 		if err != nil {
 			return err
 		}
 
-		err = repo.Save(ctx, survey)
+		return survey.RequireInvitation(cmd.InvitationRequired)
+	})
+}
+
+// ReleaseSurvey opens the survey for responses.
+func (h *CommandHandler) ReleaseSurvey(ctx context.Context, cmd surveys.ReleaseSurveyCommand) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		return survey.Release(time.Now())
 	})
 }
 
@@ -79,28 +156,184 @@ func (h *CommandHandler) AddQuestion(ctx context.Context, cmd surveys.AddQuestio
 		return err
 	}
 
-	q, err := surveys.NewQuestion(cmd.Title, *cmd.Description, cmd.QuestionOptions, cmd.AllowMultiple)
+	var description string
+	if cmd.Description != nil {
+		description = *cmd.Description
+	}
+
+	q, err := surveys.NewQuestion(cmd.Title, description, cmd.QuestionOptions, cmd.AllowMultiple)
 	if err != nil {
 		return err
 	}
 
-	return h.tx.RunTransactional(ctx, func(repo core.Repository) error {
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
+		if err != nil {
+			return err
+		}
+
+		survey.AddQuestion(q)
+
+		return nil
+	})
+}
+
+func (h *CommandHandler) AddCollaborator(ctx context.Context, cmd surveys.AddCollaboratorCommand) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	permission, err := surveys.NewCollaboratorPermission(cmd.Permission)
+	if err != nil {
+		return err
+	}
+
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionManageCollaborators, survey)
+		if err != nil {
+			return err
+		}
+
+		return survey.AddCollaborator(cmd.UserId, permission)
+	})
+}
+
+func (h *CommandHandler) RemoveCollaborator(ctx context.Context, cmd surveys.RemoveCollaboratorCommand) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	return core.Update(ctx, h.tx, core.AggregateId(surveyId), func(survey *surveys.Survey) error {
+		err := h.authorizeSurvey(ctx, auth.ActionManageCollaborators, survey)
+		if err != nil {
+			return err
+		}
+
+		return survey.RemoveCollaborator(cmd.UserId)
+	})
+}
+
+func (h *CommandHandler) CreateInvitations(ctx context.Context, cmd surveys.CreateInvitationsCommand) ([]surveys.IssuedInvitation, error) {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = surveys.CheckInvitationCount(cmd.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	var issued []surveys.IssuedInvitation
+
+	err = h.tx.RunTransactional(ctx, func(repo core.Repository) error {
+		issued = make([]surveys.IssuedInvitation, 0, cmd.Count)
+
 		survey := new(surveys.Survey)
 
-		defer survey.ClearUncommittedEvents()
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		err = h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+
+		for range cmd.Count {
+			invitation, token, err := surveys.NewInvitation(surveyId, cmd.ExpiresAt, now)
+			if err != nil {
+				return err
+			}
+
+			err = repo.Save(ctx, invitation)
+			if err != nil {
+				return err
+			}
+
+			issued = append(issued, surveys.IssuedInvitation{
+				Id:        invitation.Id,
+				Token:     token,
+				ExpiresAt: invitation.ExpiresAt,
+			})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h.logger.InfoContext(ctx, "invitations issued",
+		slog.String("surveyId", cmd.SurveyId),
+		slog.Int("count", len(issued)),
+	)
+
+	return issued, nil
+}
+
+func (h *CommandHandler) RevokeInvitation(ctx context.Context, cmd surveys.RevokeInvitationCommand) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	invitationId, err := surveys.InvitationIdFromString(cmd.InvitationId)
+	if err != nil {
+		return err
+	}
+
+	return h.tx.RunTransactional(ctx, func(repo core.Repository) error {
+		survey := new(surveys.Survey)
 
 		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
 		if err != nil {
 			return err
 		}
 
-		survey.AddQuestion(q)
+		err = h.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
+		if err != nil {
+			return err
+		}
 
-		err = repo.Save(ctx, survey)
+		invitation := new(surveys.Invitation)
+
+		defer invitation.ClearUncommittedEvents()
+
+		err = repo.Load(ctx, core.AggregateId(invitationId), invitation)
 		if err != nil {
 			return err
 		}
 
-		return nil
+		if invitation.SurveyId != surveyId {
+			return errors.New("invitation does not belong to the survey")
+		}
+
+		err = invitation.Revoke()
+		if err != nil {
+			return err
+		}
+
+		return repo.Save(ctx, invitation)
 	})
 }
+
+func (h *CommandHandler) authorizeSurvey(ctx context.Context, action auth.Action, survey *surveys.Survey) error {
+	err := auth.Authorize(ctx, h.policy, action, auth.SurveyResource(*survey))
+	if err != nil {
+		h.logDenied(ctx, action, err, slog.String("surveyId", survey.Id.String()))
+	}
+
+	return err
+}
+
+func (h *CommandHandler) logDenied(ctx context.Context, action auth.Action, err error, attrs ...any) {
+	h.logger.WarnContext(ctx, "access denied",
+		append([]any{slog.String("action", string(action)), slog.String("error", err.Error())}, attrs...)...,
+	)
+}
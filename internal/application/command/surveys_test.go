@@ -2,8 +2,13 @@ package command_test
 
 import (
 	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
 	"testing"
+	"time"
 
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/application/command"
 	"github.com/markusryoti/survey-ddd/internal/core"
 	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
@@ -13,31 +18,75 @@ import (
 func TestCreateSurvey(t *testing.T) {
 	t.Run("can create a survey", func(t *testing.T) {
 		transctional := newMockTransactionalProvider()
-		handler := command.NewCommandHandler(transctional)
+		handler := command.NewCommandHandler(transctional, auth.NewRolePolicy(), discardLogger())
 
 		description := "survey description"
 
-		survey, err := handler.CreateSurvey(context.Background(), surveys.CreateSurveyCommand{
+		survey, err := handler.CreateSurvey(authorContext(), surveys.CreateSurveyCommand{
 			Title:       "survey title",
 			Description: &description,
+			TenantId:    "tenant",
 		})
 
 		assert.Nil(t, err)
 		assert.Len(t, survey.GetUncommittedEvents(), 0)
 	})
+
+	t.Run("can't create a survey without a user", func(t *testing.T) {
+		transctional := newMockTransactionalProvider()
+		handler := command.NewCommandHandler(transctional, auth.NewRolePolicy(), discardLogger())
+
+		_, err := handler.CreateSurvey(context.Background(), surveys.CreateSurveyCommand{
+			Title:    "survey title",
+			TenantId: "tenant",
+		})
+
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	})
+
+	t.Run("analyst can't create a survey", func(t *testing.T) {
+		transctional := newMockTransactionalProvider()
+		handler := command.NewCommandHandler(transctional, auth.NewRolePolicy(), discardLogger())
+
+		ctx := auth.WithUser(context.Background(), auth.User{
+			Id:       "analyst",
+			TenantId: "tenant",
+			Roles:    []auth.Role{auth.RoleAnalyst},
+		})
+
+		_, err := handler.CreateSurvey(ctx, surveys.CreateSurveyCommand{
+			Title:    "survey title",
+			TenantId: "tenant",
+		})
+
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("can't create a survey for another tenant", func(t *testing.T) {
+		transctional := newMockTransactionalProvider()
+		handler := command.NewCommandHandler(transctional, auth.NewRolePolicy(), discardLogger())
+
+		_, err := handler.CreateSurvey(authorContext(), surveys.CreateSurveyCommand{
+			Title:    "survey title",
+			TenantId: "another-tenant",
+		})
+
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
 }
 
 func TestSetMaxParticipants(t *testing.T) {
 	t.Run("can create a survey", func(t *testing.T) {
-		ctx := context.Background()
+		ctx := authorContext()
 		transctional := newMockTransactionalProvider()
-		handler := command.NewCommandHandler(transctional)
+		handler := command.NewCommandHandler(transctional, auth.NewRolePolicy(), discardLogger())
 
 		description := "survey description"
 
 		survey, _ := handler.CreateSurvey(ctx, surveys.CreateSurveyCommand{
 			Title:       "survey title",
 			Description: &description,
+			TenantId:    "tenant",
 		})
 
 		err := handler.SetMaxParticipants(ctx, surveys.SetMaxParticipantsCommand{
@@ -49,9 +98,9 @@ func TestSetMaxParticipants(t *testing.T) {
 	})
 
 	t.Run("can add a question", func(t *testing.T) {
-		ctx := context.Background()
+		ctx := authorContext()
 		transctional := newMockTransactionalProvider()
-		handler := command.NewCommandHandler(transctional)
+		handler := command.NewCommandHandler(transctional, auth.NewRolePolicy(), discardLogger())
 
 		title := "some title"
 		description := "some description"
@@ -59,6 +108,7 @@ func TestSetMaxParticipants(t *testing.T) {
 		survey, _ := handler.CreateSurvey(ctx, surveys.CreateSurveyCommand{
 			Title:       title,
 			Description: &description,
+			TenantId:    "tenant",
 		})
 
 		questionDescription := "question description"
@@ -71,6 +121,140 @@ func TestSetMaxParticipants(t *testing.T) {
 	})
 }
 
+func TestReleaseSurvey(t *testing.T) {
+	newSurvey := func(t *testing.T) *surveys.Survey {
+		survey, err := surveys.NewSurvey("survey title", nil, "tenant", "author")
+		assert.Nil(t, err)
+		assert.Nil(t, survey.SetMaxParticipants(3))
+		assert.Nil(t, survey.SetEndTime(time.Now().Add(time.Hour)))
+
+		return survey
+	}
+
+	t.Run("owner can release a survey", func(t *testing.T) {
+		tx := &surveyProvider{survey: newSurvey(t)}
+		handler := command.NewCommandHandler(tx, auth.NewRolePolicy(), discardLogger())
+
+		err := handler.ReleaseSurvey(authorContext(), surveys.ReleaseSurveyCommand{SurveyId: tx.survey.Id.String()})
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.Released, tx.survey.Status())
+	})
+
+	t.Run("analyst can't release a survey", func(t *testing.T) {
+		tx := &surveyProvider{survey: newSurvey(t)}
+		handler := command.NewCommandHandler(tx, auth.NewRolePolicy(), discardLogger())
+
+		ctx := auth.WithUser(context.Background(), auth.User{
+			Id:       "analyst",
+			TenantId: "tenant",
+			Roles:    []auth.Role{auth.RoleAnalyst},
+		})
+
+		err := handler.ReleaseSurvey(ctx, surveys.ReleaseSurveyCommand{SurveyId: tx.survey.Id.String()})
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+}
+
+func TestCollaborators(t *testing.T) {
+	t.Run("can't add collaborator with invalid permission", func(t *testing.T) {
+		handler := command.NewCommandHandler(newMockTransactionalProvider(), auth.NewRolePolicy(), discardLogger())
+
+		err := handler.AddCollaborator(authorContext(), surveys.AddCollaboratorCommand{
+			SurveyId:   surveys.NewSurveyId().String(),
+			UserId:     "user",
+			Permission: "owner",
+		})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCreateInvitations(t *testing.T) {
+	t.Run("can't create invitations without a count", func(t *testing.T) {
+		bus := newCommandBus(newMockTransactionalProvider())
+
+		_, err := bus.Dispatch(authorContext(), surveys.CreateInvitationsCommand{
+			SurveyId:  surveys.NewSurveyId().String(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("can't create too many invitations at once", func(t *testing.T) {
+		bus := newCommandBus(newMockTransactionalProvider())
+
+		_, err := bus.Dispatch(authorContext(), surveys.CreateInvitationsCommand{
+			SurveyId:  surveys.NewSurveyId().String(),
+			Count:     100000,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.NotNil(t, err)
+	})
+
+	t.Run("handler checks the count without the bus", func(t *testing.T) {
+		handler := command.NewCommandHandler(newMockTransactionalProvider(), auth.NewRolePolicy(), discardLogger())
+
+		_, err := handler.CreateInvitations(authorContext(), surveys.CreateInvitationsCommand{
+			SurveyId:  surveys.NewSurveyId().String(),
+			Count:     -1,
+			ExpiresAt: time.Now().Add(time.Hour),
+		})
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCommandBus(t *testing.T) {
+	t.Run("dispatches to the registered handler", func(t *testing.T) {
+		bus := newCommandBus(newMockTransactionalProvider())
+
+		survey, err := core.Dispatch[*surveys.Survey](authorContext(), bus, surveys.CreateSurveyCommand{
+			Title:    "survey title",
+			TenantId: "tenant",
+		})
+
+		assert.Nil(t, err)
+		assert.NotNil(t, survey)
+	})
+
+	t.Run("rejects unauthenticated commands", func(t *testing.T) {
+		bus := newCommandBus(newMockTransactionalProvider())
+
+		_, err := bus.Dispatch(context.Background(), surveys.CreateSurveyCommand{
+			Title:    "survey title",
+			TenantId: "tenant",
+		})
+
+		assert.ErrorIs(t, err, auth.ErrUnauthenticated)
+	})
+
+	t.Run("rejects invalid commands", func(t *testing.T) {
+		bus := newCommandBus(newMockTransactionalProvider())
+
+		_, err := bus.Dispatch(authorContext(), surveys.CreateSurveyCommand{
+			TenantId: "tenant",
+		})
+
+		assert.NotNil(t, err)
+	})
+}
+
+func newCommandBus(tx core.TransactionProvider) *core.CommandBus {
+	bus := core.NewCommandBus(
+		auth.AuthenticationMiddleware(),
+		core.ValidationMiddleware(),
+	)
+	command.NewCommandHandler(tx, auth.NewRolePolicy(), discardLogger()).Register(bus)
+
+	return bus
+}
+
+func authorContext() context.Context {
+	return auth.WithUser(context.Background(), auth.User{
+		Id:       "author",
+		TenantId: "tenant",
+		Roles:    []auth.Role{auth.RoleSurveyAuthor},
+	})
+}
+
 type mockTransactionalProvider struct {
 }
 
@@ -81,3 +265,38 @@ func newMockTransactionalProvider() *mockTransactionalProvider {
 func (t *mockTransactionalProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
 	return nil
 }
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// surveyProvider serves a single survey and keeps it when it's saved.
+type surveyProvider struct {
+	survey *surveys.Survey
+}
+
+func (p *surveyProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	return fn(p)
+}
+
+func (p *surveyProvider) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	data, err := json.Marshal(p.survey)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, aggregate)
+}
+
+func (p *surveyProvider) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return core.ErrNotEventSourced
+}
+
+func (p *surveyProvider) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return core.ErrNotEventSourced
+}
+
+func (p *surveyProvider) Save(ctx context.Context, aggregate core.Aggregate) error {
+	p.survey = aggregate.(*surveys.Survey)
+	return nil
+}
@@ -2,22 +2,55 @@ package query
 
 import (
 	"context"
+	"errors"
+	"log/slog"
+	"time"
 
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/core"
 	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/markusryoti/survey-ddd/internal/ports"
+)
+
+const (
+	defaultHistoryPageSize = 50
+	maxHistoryPageSize     = 500
 )
 
 type QueryHandler struct {
-	tx core.TransactionProvider
+	tx          core.TransactionProvider
+	policy      auth.Policy
+	invitations ports.InvitationReader
+	responses   ports.ResponseReader
+	events      core.EventStore
+	logger      *slog.Logger
+	observer    core.QueryObserver
 }
 
-func NewQueryHandler(transactional core.TransactionProvider) *QueryHandler {
+func NewQueryHandler(
+	transactional core.TransactionProvider,
+	policy auth.Policy,
+	invitations ports.InvitationReader,
+	responses ports.ResponseReader,
+	events core.EventStore,
+	logger *slog.Logger,
+	observer core.QueryObserver,
+) *QueryHandler {
 	return &QueryHandler{
-		tx: transactional,
+		tx:          transactional,
+		policy:      policy,
+		invitations: invitations,
+		responses:   responses,
+		events:      events,
+		logger:      logger,
+		observer:    observer,
 	}
 }
 
-func (q *QueryHandler) GetSurvey(ctx context.Context, id string) (surveys.Survey, error) {
+func (q *QueryHandler) GetSurvey(ctx context.Context, id string) (_ surveys.Survey, err error) {
+	ctx, done := q.observe(ctx, "GetSurvey")
+	defer func() { done(err) }()
+
 	survey := new(surveys.Survey)
 
 	surveyId, err := surveys.SurveyIdFromString(id)
@@ -26,8 +59,280 @@ func (q *QueryHandler) GetSurvey(ctx context.Context, id string) (surveys.Survey
 	}
 
 	err = q.tx.RunTransactional(ctx, func(repo core.Repository) error {
-		return repo.Load(ctx, core.AggregateId(surveyId), survey)
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		return q.authorizeSurvey(ctx, auth.ActionViewSurvey, survey)
+	})
+	if err != nil {
+		return surveys.Survey{}, err
+	}
+
+	return *survey, nil
+}
+
+// GetSurveyAt returns the survey as it was at the given version. Past states
+// are part of the history of the survey and require access to it.
+func (q *QueryHandler) GetSurveyAt(ctx context.Context, id string, version int) (_ surveys.Survey, err error) {
+	ctx, done := q.observe(ctx, "GetSurveyAt")
+	defer func() { done(err) }()
+
+	if version < 1 {
+		return surveys.Survey{}, errors.New("invalid version")
+	}
+
+	return q.getPastSurvey(ctx, id, func(repo core.Repository, id core.AggregateId, survey *surveys.Survey) error {
+		return repo.LoadAt(ctx, id, version, survey)
+	})
+}
+
+// GetSurveyAsOf returns the survey as it was at the given time.
+func (q *QueryHandler) GetSurveyAsOf(ctx context.Context, id string, asOf time.Time) (_ surveys.Survey, err error) {
+	ctx, done := q.observe(ctx, "GetSurveyAsOf")
+	defer func() { done(err) }()
+
+	return q.getPastSurvey(ctx, id, func(repo core.Repository, id core.AggregateId, survey *surveys.Survey) error {
+		return repo.LoadAsOf(ctx, id, asOf, survey)
+	})
+}
+
+// getPastSurvey authorizes the caller against the current survey, since
+// collaborators may have changed since, and then loads the past one.
+func (q *QueryHandler) getPastSurvey(
+	ctx context.Context,
+	id string,
+	load func(repo core.Repository, id core.AggregateId, survey *surveys.Survey) error,
+) (surveys.Survey, error) {
+	surveyId, err := surveys.SurveyIdFromString(id)
+	if err != nil {
+		return surveys.Survey{}, err
+	}
+
+	past := new(surveys.Survey)
+
+	err = q.tx.RunTransactional(ctx, func(repo core.Repository) error {
+		current := new(surveys.Survey)
+
+		err := repo.Load(ctx, core.AggregateId(surveyId), current)
+		if err != nil {
+			return err
+		}
+
+		err = q.authorizeSurvey(ctx, auth.ActionViewHistory, current)
+		if err != nil {
+			return err
+		}
+
+		return load(repo, core.AggregateId(surveyId), past)
+	})
+	if err != nil {
+		return surveys.Survey{}, err
+	}
+
+	return *past, nil
+}
+
+func (q *QueryHandler) ListInvitations(ctx context.Context, id string) (_ []surveys.Invitation, err error) {
+	ctx, done := q.observe(ctx, "ListInvitations")
+	defer func() { done(err) }()
+
+	surveyId, err := surveys.SurveyIdFromString(id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.tx.RunTransactional(ctx, func(repo core.Repository) error {
+		survey := new(surveys.Survey)
+
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		return q.authorizeSurvey(ctx, auth.ActionEditSurvey, survey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return q.invitations.ListInvitations(ctx, surveyId)
+}
+
+// SurveyResults are the answers to a survey counted over its submitted
+// responses. Unlike the responses themselves, they don't tell how any one
+// respondent answered.
+type SurveyResults struct {
+	SurveyId  surveys.SurveyId
+	Submitted int
+	Questions []QuestionResults
+}
+
+type QuestionResults struct {
+	QuestionId surveys.QuestionId
+	Answered   int
+	Choices    map[surveys.QuestionOptionId]int
+}
+
+func (q *QueryHandler) GetSurveyResults(ctx context.Context, id string) (_ SurveyResults, err error) {
+	ctx, done := q.observe(ctx, "GetSurveyResults")
+	defer func() { done(err) }()
+
+	survey, responses, err := q.loadResponses(ctx, id, auth.ActionViewResults)
+	if err != nil {
+		return SurveyResults{}, err
+	}
+
+	results := SurveyResults{
+		SurveyId:  survey.Id,
+		Questions: make([]QuestionResults, 0, len(survey.Questions)),
+	}
+
+	index := make(map[surveys.QuestionId]int, len(survey.Questions))
+	for i, question := range survey.Questions {
+		index[question.Id] = i
+		results.Questions = append(results.Questions, QuestionResults{
+			QuestionId: question.Id,
+			Choices:    make(map[surveys.QuestionOptionId]int, len(question.QuestionOptions)),
+		})
+	}
+
+	for _, response := range responses {
+		if response.Status != surveys.ResponseStatusSubmitted {
+			continue
+		}
+
+		results.Submitted++
+
+		for _, answer := range response.Responses {
+			i, ok := index[answer.QuestionId]
+			if !ok {
+				continue
+			}
+
+			results.Questions[i].Answered++
+			for _, choice := range answer.Choices {
+				results.Questions[i].Choices[choice]++
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// ListResponses returns the individual responses to a survey.
+func (q *QueryHandler) ListResponses(ctx context.Context, id string) (_ []surveys.SurveyResponse, err error) {
+	ctx, done := q.observe(ctx, "ListResponses")
+	defer func() { done(err) }()
+
+	_, responses, err := q.loadResponses(ctx, id, auth.ActionViewResponses)
+
+	return responses, err
+}
+
+// loadResponses loads the survey and, if the user may perform the action
+// on it, its responses.
+func (q *QueryHandler) loadResponses(ctx context.Context, id string, action auth.Action) (*surveys.Survey, []surveys.SurveyResponse, error) {
+	surveyId, err := surveys.SurveyIdFromString(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	survey := new(surveys.Survey)
+
+	err = q.tx.RunTransactional(ctx, func(repo core.Repository) error {
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		return q.authorizeSurvey(ctx, action, survey)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	responses, err := q.responses.ListResponses(ctx, surveyId)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return survey, responses, nil
+}
+
+// SurveyHistory is a page of the events of a survey.
+type SurveyHistory struct {
+	Events  []core.StoredEvent
+	HasMore bool
+}
+
+// GetSurveyHistory returns a page of the events of a survey, starting after
+// the given version. A limit of zero returns the default page size.
+func (q *QueryHandler) GetSurveyHistory(ctx context.Context, id string, after int, limit int) (_ SurveyHistory, err error) {
+	ctx, done := q.observe(ctx, "GetSurveyHistory")
+	defer func() { done(err) }()
+
+	surveyId, err := surveys.SurveyIdFromString(id)
+	if err != nil {
+		return SurveyHistory{}, err
+	}
+
+	if after < 0 || limit < 0 {
+		return SurveyHistory{}, errors.New("invalid page")
+	}
+
+	if limit == 0 {
+		limit = defaultHistoryPageSize
+	}
+
+	limit = min(limit, maxHistoryPageSize)
+
+	err = q.tx.RunTransactional(ctx, func(repo core.Repository) error {
+		survey := new(surveys.Survey)
+
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		return q.authorizeSurvey(ctx, auth.ActionViewHistory, survey)
 	})
+	if err != nil {
+		return SurveyHistory{}, err
+	}
+
+	// One extra event tells whether there is another page.
+	events, err := q.events.ReadEvents(ctx, core.AggregateId(surveyId), after, limit+1)
+	if err != nil {
+		return SurveyHistory{}, err
+	}
+
+	if len(events) > limit {
+		return SurveyHistory{Events: events[:limit], HasMore: true}, nil
+	}
+
+	return SurveyHistory{Events: events}, nil
+}
+
+func (q *QueryHandler) authorizeSurvey(ctx context.Context, action auth.Action, survey *surveys.Survey) error {
+	err := auth.Authorize(ctx, q.policy, action, auth.SurveyResource(*survey))
+	if err != nil {
+		q.logger.WarnContext(ctx, "access denied",
+			slog.String("action", string(action)),
+			slog.String("surveyId", survey.Id.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+
+	return err
+}
+
+// observe notifies the observer, when there's one, of a query.
+func (q *QueryHandler) observe(ctx context.Context, name string) (context.Context, func(err error)) {
+	if q.observer == nil {
+		return ctx, func(err error) {}
+	}
 
-	return *survey, err
+	return q.observer.ObserveQuery(ctx, name)
 }
@@ -0,0 +1,273 @@
+package query_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/application/query"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetSurveyHistory(t *testing.T) {
+	survey, err := surveys.NewSurvey("title", nil, "tenant", "owner")
+	assert.Nil(t, err)
+
+	events := &memoryEventStore{}
+	for version := 1; version <= 5; version++ {
+		events.events = append(events.events, core.StoredEvent{
+			AggregateId: survey.ID(),
+			Type:        "survey-created",
+			Version:     version,
+		})
+	}
+
+	handler := query.NewQueryHandler(newSurveyTransactionalProvider(survey), auth.NewRolePolicy(), nil, nil, events, discardLogger(), nil)
+
+	owner := auth.WithUser(context.Background(), auth.User{
+		Id:       "owner",
+		TenantId: "tenant",
+		Roles:    []auth.Role{auth.RoleSurveyAuthor},
+	})
+
+	t.Run("returns the history a page at a time", func(t *testing.T) {
+		history, err := handler.GetSurveyHistory(owner, survey.Id.String(), 0, 2)
+		assert.Nil(t, err)
+		assert.Len(t, history.Events, 2)
+		assert.True(t, history.HasMore)
+
+		history, err = handler.GetSurveyHistory(owner, survey.Id.String(), 4, 2)
+		assert.Nil(t, err)
+		assert.Len(t, history.Events, 1)
+		assert.Equal(t, 5, history.Events[0].Version)
+		assert.False(t, history.HasMore)
+	})
+
+	t.Run("analyst can't read the history", func(t *testing.T) {
+		ctx := auth.WithUser(context.Background(), auth.User{
+			Id:       "analyst",
+			TenantId: "tenant",
+			Roles:    []auth.Role{auth.RoleAnalyst},
+		})
+
+		_, err := handler.GetSurveyHistory(ctx, survey.Id.String(), 0, 0)
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("rejects invalid pages", func(t *testing.T) {
+		_, err := handler.GetSurveyHistory(owner, survey.Id.String(), -1, 0)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestGetPastSurvey(t *testing.T) {
+	survey, err := surveys.NewSurvey("title", nil, "tenant", "owner")
+	assert.Nil(t, err)
+
+	created := time.Now()
+	question, err := surveys.NewQuestion("question", "", []string{"a", "b"}, false)
+	assert.Nil(t, err)
+	survey.AddQuestion(question)
+	assert.Nil(t, survey.SetAnonymityMode(surveys.Identified))
+
+	handler := query.NewQueryHandler(newSurveyTransactionalProvider(survey), auth.NewRolePolicy(), nil, nil, nil, discardLogger(), nil)
+
+	owner := auth.WithUser(context.Background(), auth.User{
+		Id:       "owner",
+		TenantId: "tenant",
+		Roles:    []auth.Role{auth.RoleSurveyAuthor},
+	})
+
+	t.Run("loads the survey at a version", func(t *testing.T) {
+		past, err := handler.GetSurveyAt(owner, survey.Id.String(), 2)
+		assert.Nil(t, err)
+		assert.Equal(t, 2, past.Version())
+		assert.Len(t, past.Questions, 1)
+		assert.NotEqual(t, surveys.Identified, past.AnonymityMode)
+	})
+
+	t.Run("loads the survey as of a time", func(t *testing.T) {
+		past, err := handler.GetSurveyAsOf(owner, survey.Id.String(), created)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, past.Version())
+		assert.Equal(t, "title", past.Title)
+		assert.Empty(t, past.Questions)
+	})
+
+	t.Run("analyst can't load past states", func(t *testing.T) {
+		ctx := auth.WithUser(context.Background(), auth.User{
+			Id:       "analyst",
+			TenantId: "tenant",
+			Roles:    []auth.Role{auth.RoleAnalyst},
+		})
+
+		_, err := handler.GetSurveyAt(ctx, survey.Id.String(), 1)
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("rejects invalid versions", func(t *testing.T) {
+		_, err := handler.GetSurveyAt(owner, survey.Id.String(), 0)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestSurveyResults(t *testing.T) {
+	survey, err := surveys.NewSurvey("title", nil, "tenant", "owner")
+	assert.Nil(t, err)
+
+	question, err := surveys.NewQuestion("question", "", []string{"a", "b"}, false)
+	assert.Nil(t, err)
+	survey.AddQuestion(question)
+
+	option := question.QuestionOptions[0].Id
+
+	submitted := surveys.NewSurveyResponse(survey.Id, "")
+	assert.Nil(t, submitted.AddResponseToQuestion(question.Id, []surveys.QuestionOptionId{option}))
+	assert.Nil(t, submitted.Submit())
+
+	draft := surveys.NewSurveyResponse(survey.Id, "")
+	assert.Nil(t, draft.AddResponseToQuestion(question.Id, []surveys.QuestionOptionId{option}))
+
+	responses := &memoryResponseReader{responses: []surveys.SurveyResponse{*submitted, *draft}}
+	handler := query.NewQueryHandler(newSurveyTransactionalProvider(survey), auth.NewRolePolicy(), nil, responses, nil, discardLogger(), nil)
+
+	userContext := func(role auth.Role) context.Context {
+		return auth.WithUser(context.Background(), auth.User{Id: "user", TenantId: "tenant", Roles: []auth.Role{role}})
+	}
+
+	t.Run("counts the answers of submitted responses", func(t *testing.T) {
+		results, err := handler.GetSurveyResults(userContext(auth.RoleAnalyst), survey.Id.String())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, results.Submitted)
+		assert.Len(t, results.Questions, 1)
+		assert.Equal(t, 1, results.Questions[0].Answered)
+		assert.Equal(t, 1, results.Questions[0].Choices[option])
+	})
+
+	matrix := map[auth.Role]struct{ results, responses bool }{
+		auth.RoleTenantAdmin:  {results: true, responses: true},
+		auth.RoleSurveyAuthor: {results: false, responses: false},
+		auth.RoleAnalyst:      {results: true, responses: false},
+		auth.RoleRespondent:   {results: false, responses: false},
+	}
+
+	for role, allowed := range matrix {
+		t.Run(string(role)+" reading results and responses", func(t *testing.T) {
+			ctx := userContext(role)
+
+			_, err := handler.GetSurveyResults(ctx, survey.Id.String())
+			if allowed.results {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorIs(t, err, auth.ErrForbidden)
+			}
+
+			_, err = handler.ListResponses(ctx, survey.Id.String())
+			if allowed.responses {
+				assert.Nil(t, err)
+			} else {
+				assert.ErrorIs(t, err, auth.ErrForbidden)
+			}
+		})
+	}
+
+	t.Run("owner can read the responses", func(t *testing.T) {
+		ctx := auth.WithUser(context.Background(), auth.User{Id: "owner", TenantId: "tenant", Roles: []auth.Role{auth.RoleSurveyAuthor}})
+
+		res, err := handler.ListResponses(ctx, survey.Id.String())
+		assert.Nil(t, err)
+		assert.Len(t, res, 2)
+	})
+}
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// surveyTransactionalProvider serves a single survey, rebuilding its past
+// states from the events it has produced.
+type surveyTransactionalProvider struct {
+	survey *surveys.Survey
+	events []core.DomainEvent
+}
+
+func newSurveyTransactionalProvider(survey *surveys.Survey) *surveyTransactionalProvider {
+	return &surveyTransactionalProvider{
+		survey: survey,
+		events: survey.GetUncommittedEvents(),
+	}
+}
+
+func (p *surveyTransactionalProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	return fn(p)
+}
+
+func (p *surveyTransactionalProvider) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	data, err := json.Marshal(p.survey)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, aggregate)
+}
+
+func (p *surveyTransactionalProvider) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return p.replay(aggregate, func(i int, event core.DomainEvent) bool {
+		return i < version
+	})
+}
+
+func (p *surveyTransactionalProvider) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return p.replay(aggregate, func(i int, event core.DomainEvent) bool {
+		return !event.OccurredAt().After(asOf)
+	})
+}
+
+func (p *surveyTransactionalProvider) replay(aggregate core.Aggregate, include func(i int, event core.DomainEvent) bool) error {
+	version := 0
+
+	for i, event := range p.events {
+		if include(i, event) {
+			aggregate.(core.EventSourced).ApplyEvent(event)
+			version = i + 1
+		}
+	}
+
+	aggregate.SetVersion(version)
+
+	return nil
+}
+
+func (p *surveyTransactionalProvider) Save(ctx context.Context, aggregate core.Aggregate) error {
+	return nil
+}
+
+type memoryEventStore struct {
+	events []core.StoredEvent
+}
+
+func (s *memoryEventStore) ReadEvents(ctx context.Context, id core.AggregateId, after int, limit int) ([]core.StoredEvent, error) {
+	res := make([]core.StoredEvent, 0)
+
+	for _, e := range s.events {
+		if e.AggregateId == id && e.Version > after && len(res) < limit {
+			res = append(res, e)
+		}
+	}
+
+	return res, nil
+}
+
+type memoryResponseReader struct {
+	responses []surveys.SurveyResponse
+}
+
+func (r *memoryResponseReader) ListResponses(ctx context.Context, surveyId surveys.SurveyId) ([]surveys.SurveyResponse, error) {
+	return r.responses, nil
+}
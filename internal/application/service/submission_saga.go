@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/markusryoti/survey-ddd/internal/ports"
+)
+
+// SubmissionSaga counts submitted responses in their survey outside the
+// transaction that submitted them, so responses don't contend on the survey
+// row when they are submitted.
+//
+// The saga is started in the same transaction as the submission and
+// processed once the submission has been committed. If the survey can't
+// accept the response, the response is rejected instead. The state of every
+// saga is stored, so sagas that failed or were interrupted by a restart are
+// picked up by Resume, which has to be run periodically.
+type SubmissionSaga struct {
+	txProvider core.TransactionProvider
+	pending    ports.SubmissionSagaReader
+	policy     core.RetryPolicy
+}
+
+func NewSubmissionSaga(txProvider core.TransactionProvider, pending ports.SubmissionSagaReader) *SubmissionSaga {
+	return &SubmissionSaga{
+		txProvider: txProvider,
+		pending:    pending,
+		policy:     core.DefaultRetryPolicy(),
+	}
+}
+
+// Register processes the saga of every response submitted on the bus after
+// the submission has been committed.
+func (s *SubmissionSaga) Register(bus *core.EventBus) {
+	core.Subscribe(bus, func(ctx context.Context, event surveys.ResponseSubmitted) error {
+		return s.Process(ctx, event.Id)
+	})
+}
+
+// Process counts the response in its survey, or rejects the response if the
+// survey is full or no longer accepts submissions. Sagas that have already
+// finished are left as they are.
+func (s *SubmissionSaga) Process(ctx context.Context, responseId surveys.SurveyResponseId) error {
+	ctx = core.WithIsolationLevel(ctx, core.IsolationSerializable)
+
+	return core.RetryOnConflict(ctx, s.policy, func() error {
+		return s.txProvider.RunTransactional(ctx, func(repo core.Repository) error {
+			saga := new(surveys.SubmissionSaga)
+
+			err := repo.Load(ctx, core.AggregateId(responseId), saga)
+			if err != nil {
+				return err
+			}
+
+			if saga.Status != surveys.SubmissionSagaStatusPending {
+				return nil
+			}
+
+			survey := new(surveys.Survey)
+
+			err = repo.Load(ctx, core.AggregateId(saga.SurveyId), survey)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+
+			survey.ReleaseExpiredSlots(now)
+
+			err = survey.AcceptSubmission(responseId, now)
+			if err != nil {
+				return s.reject(ctx, repo, saga, err.Error())
+			}
+
+			err = saga.Accept()
+			if err != nil {
+				return err
+			}
+
+			err = repo.Save(ctx, survey)
+			if err != nil {
+				return err
+			}
+
+			return repo.Save(ctx, saga)
+		})
+	})
+}
+
+// reject compensates for a submission the survey didn't accept.
+func (s *SubmissionSaga) reject(ctx context.Context, repo core.Repository, saga *surveys.SubmissionSaga, reason string) error {
+	response := new(surveys.SurveyResponse)
+
+	err := repo.Load(ctx, core.AggregateId(saga.ResponseId), response)
+	if err != nil {
+		return err
+	}
+
+	err = response.Reject(reason)
+	if err != nil {
+		return err
+	}
+
+	err = saga.Reject(reason)
+	if err != nil {
+		return err
+	}
+
+	err = repo.Save(ctx, response)
+	if err != nil {
+		return err
+	}
+
+	return repo.Save(ctx, saga)
+}
+
+// Resume processes all sagas that haven't finished yet.
+func (s *SubmissionSaga) Resume(ctx context.Context) error {
+	ids, err := s.pending.PendingSubmissionSagas(ctx)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+
+	for _, id := range ids {
+		err = s.Process(ctx, id)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,160 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/application/service"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubmissionSaga(t *testing.T) {
+	t.Run("rejects responses the survey can't accept", func(t *testing.T) {
+		ctx := context.Background()
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 3)
+
+		saga := service.NewSubmissionSaga(store, pendingSagas{})
+
+		var responses []surveys.SurveyResponseId
+		for range 4 {
+			responses = append(responses, seedSubmittedResponse(t, store, survey.Id))
+		}
+
+		for _, id := range responses {
+			err := saga.Process(ctx, id)
+			assert.Nil(t, err)
+		}
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 3, loaded.AnswersReceived())
+
+		for _, id := range responses[:3] {
+			assert.Equal(t, surveys.SubmissionSagaStatusAccepted, loadSaga(t, store, id).Status)
+			assert.Equal(t, surveys.ResponseStatusSubmitted, loadResponse(t, store, id).Status)
+		}
+
+		rejected := loadSaga(t, store, responses[3])
+		assert.Equal(t, surveys.SubmissionSagaStatusRejected, rejected.Status)
+		assert.NotEmpty(t, rejected.Reason)
+		assert.Equal(t, surveys.ResponseStatusRejected, loadResponse(t, store, responses[3]).Status)
+	})
+
+	t.Run("processing a finished saga does nothing", func(t *testing.T) {
+		ctx := context.Background()
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 3)
+
+		saga := service.NewSubmissionSaga(store, pendingSagas{})
+		id := seedSubmittedResponse(t, store, survey.Id)
+
+		for range 2 {
+			err := saga.Process(ctx, id)
+			assert.Nil(t, err)
+		}
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 1, loaded.AnswersReceived())
+	})
+
+	t.Run("resumes pending sagas", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 3)
+
+		pending := pendingSagas{
+			seedSubmittedResponse(t, store, survey.Id),
+			seedSubmittedResponse(t, store, survey.Id),
+		}
+
+		err := service.NewSubmissionSaga(store, pending).Resume(context.Background())
+		assert.Nil(t, err)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 2, loaded.AnswersReceived())
+	})
+
+	t.Run("submissions never exceed max participants", func(t *testing.T) {
+		const maxParticipants = 5
+		const respondents = 50
+
+		ctx := respondentContext("respondent")
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, maxParticipants)
+
+		bus := core.NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+		tx := core.NewPublishingTransactionProvider(store, bus)
+
+		service.NewSubmissionSaga(tx, pendingSagas{}).Register(bus)
+
+		srv := service.NewSurveyService(tx, auth.NewRolePolicy(), service.WithSubmissionSaga())
+
+		var wg sync.WaitGroup
+		errs := newErrorCollector()
+
+		for range respondents {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{
+					SurveyId: survey.Id.String(),
+				})
+				if err != nil {
+					errs.add(err)
+				}
+			}()
+		}
+
+		wg.Wait()
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, maxParticipants, loaded.AnswersReceived())
+		assert.Empty(t, errs.unexpected())
+	})
+}
+
+func seedSubmittedResponse(t *testing.T, store *memoryTransactionalProvider, surveyId surveys.SurveyId) surveys.SurveyResponseId {
+	response := surveys.NewSurveyResponse(surveyId, "")
+
+	err := response.Submit()
+	assert.Nil(t, err)
+
+	store.seed(t, response)
+	store.seed(t, surveys.NewSubmissionSaga(response.Id, surveyId))
+
+	return response.Id
+}
+
+func loadSaga(t *testing.T, store *memoryTransactionalProvider, id surveys.SurveyResponseId) *surveys.SubmissionSaga {
+	saga := new(surveys.SubmissionSaga)
+
+	err := store.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Load(context.Background(), core.AggregateId(id), saga)
+	})
+	assert.Nil(t, err)
+
+	return saga
+}
+
+func loadResponse(t *testing.T, store *memoryTransactionalProvider, id surveys.SurveyResponseId) *surveys.SurveyResponse {
+	response := new(surveys.SurveyResponse)
+
+	err := store.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Load(context.Background(), core.AggregateId(id), response)
+	})
+	assert.Nil(t, err)
+
+	return response
+}
+
+type pendingSagas []surveys.SurveyResponseId
+
+func (p pendingSagas) PendingSubmissionSagas(ctx context.Context) ([]surveys.SurveyResponseId, error) {
+	return p, nil
+}
@@ -2,64 +2,397 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/core"
 	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
 )
 
+// slotTTL is how long a started response holds a participant slot.
+const slotTTL = 30 * time.Minute
+
+// SurveyService lets respondents answer surveys. Every operation loads and
+// saves the aggregates it touches through the repository of a single
+// transaction, so they commit or roll back together.
 type SurveyService struct {
-	repo       core.Repository
-	txProvider core.TransactionProvider
+	txProvider     core.TransactionProvider
+	policy         auth.Policy
+	submissionSaga bool
 }
 
-func NewSurveyService(
-	repo core.Repository,
-	txProvider core.TransactionProvider,
-) *SurveyService {
-	return &SurveyService{
-		repo:       repo,
-		txProvider: txProvider,
+type SurveyServiceOption func(*SurveyService)
+
+// WithSubmissionSaga leaves counting submitted responses in their survey to
+// the SubmissionSaga instead of doing it in the submitting transaction. The
+// saga has to be registered on the event bus of the transaction provider.
+func WithSubmissionSaga() SurveyServiceOption {
+	return func(s *SurveyService) {
+		s.submissionSaga = true
+	}
+}
+
+func NewSurveyService(txProvider core.TransactionProvider, policy auth.Policy, opts ...SurveyServiceOption) *SurveyService {
+	s := &SurveyService{
+		txProvider: core.NewRetryingTransactionProvider(txProvider, core.DefaultRetryPolicy()),
+		policy:     policy,
+	}
+
+	for _, opt := range opts {
+		opt(s)
 	}
+
+	return s
 }
 
 type ResponseToSurveyCmd struct {
-	SurveyId string
+	SurveyId        string
+	InvitationToken string
 }
 
 func (s *SurveyService) AddResponseToQuestion(ctx context.Context, cmd ResponseToSurveyCmd) error {
-	err := s.txProvider.RunTransactional(ctx, func(repo core.Repository) error {
-		surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	user, _ := auth.UserFromContext(ctx)
+
+	return s.txProvider.RunTransactional(ctx, func(repo core.Repository) error {
+		survey := new(surveys.Survey)
+
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		err = s.authorize(ctx, survey)
+		if err != nil {
+			return err
+		}
+
+		invitation, err := loadInvitation(ctx, repo, surveyId, cmd.InvitationToken)
+		if err != nil {
+			return err
+		}
+
+		response, err := newResponse(survey, user, invitation)
+		if err != nil {
+			return err
+		}
+
+		if invitation != nil {
+			err = redeemInvitation(ctx, repo, survey, invitation, cmd.InvitationToken, response.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		if s.submissionSaga {
+			err = response.Submit()
+			if err != nil {
+				return err
+			}
+
+			err = saveResponse(ctx, repo, response)
+			if err != nil {
+				return err
+			}
+
+			return repo.Save(ctx, surveys.NewSubmissionSaga(response.Id, response.SurveyId))
+		}
+
+		now := time.Now()
+
+		survey.ReleaseExpiredSlots(now)
+
+		err = survey.SubmissionReceived(now)
+		if err != nil {
+			return err
+		}
+
+		err = saveResponse(ctx, repo, response)
 		if err != nil {
 			return err
 		}
 
-		response := surveys.NewSurveyResponse(surveyId)
+		return repo.Save(ctx, survey)
+	})
+}
+
+type StartResponseCmd struct {
+	SurveyId        string
+	InvitationToken string
+}
+
+// StartResponse creates a response and reserves a participant slot for it.
+// The slot is held until the response is submitted or the reservation
+// expires.
+func (s *SurveyService) StartResponse(ctx context.Context, cmd StartResponseCmd) (surveys.SurveyResponseId, error) {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return surveys.SurveyResponseId{}, err
+	}
+
+	user, _ := auth.UserFromContext(ctx)
+
+	var responseId surveys.SurveyResponseId
 
+	err = s.txProvider.RunTransactional(ctx, func(repo core.Repository) error {
 		survey := new(surveys.Survey)
 
-		err = s.repo.Load(ctx, core.AggregateId(surveyId), survey)
+		err := repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		err = s.authorize(ctx, survey)
+		if err != nil {
+			return err
+		}
+
+		invitation, err := loadInvitation(ctx, repo, surveyId, cmd.InvitationToken)
+		if err != nil {
+			return err
+		}
+
+		response, err := newResponse(survey, user, invitation)
 		if err != nil {
 			return err
 		}
 
-		err = survey.SubmissionReceived(time.Now())
+		if invitation != nil {
+			err = redeemInvitation(ctx, repo, survey, invitation, cmd.InvitationToken, response.Id)
+			if err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+
+		survey.ReleaseExpiredSlots(now)
+
+		err = survey.ReserveSlot(response.Id, now, slotTTL)
 		if err != nil {
 			return err
 		}
 
-		err = s.repo.Save(ctx, response)
+		err = saveResponse(ctx, repo, response)
 		if err != nil {
 			return err
 		}
 
-		err = s.repo.Save(ctx, survey)
+		err = repo.Save(ctx, survey)
 		if err != nil {
 			return err
 		}
 
+		responseId = response.Id
+
 		return nil
 	})
 
+	return responseId, err
+}
+
+type SubmitResponseCmd struct {
+	SurveyId   string
+	ResponseId string
+	// InvitationToken identifies respondents of pseudonymous surveys.
+	InvitationToken string
+}
+
+// SubmitResponse submits a started response of the calling respondent and
+// confirms its slot. Without the submission saga it runs serializably, as
+// the count of responses decides whether the survey still accepts
+// submissions.
+func (s *SurveyService) SubmitResponse(ctx context.Context, cmd SubmitResponseCmd) error {
+	surveyId, err := surveys.SurveyIdFromString(cmd.SurveyId)
+	if err != nil {
+		return err
+	}
+
+	responseId, err := surveys.SurveyResponseIdFromString(cmd.ResponseId)
+	if err != nil {
+		return err
+	}
+
+	user, _ := auth.UserFromContext(ctx)
+
+	if !s.submissionSaga {
+		ctx = core.WithIsolationLevel(ctx, core.IsolationSerializable)
+	}
+
+	return s.txProvider.RunTransactional(ctx, func(repo core.Repository) error {
+		response := new(surveys.SurveyResponse)
+
+		err := repo.Load(ctx, core.AggregateId(responseId), response)
+		if err != nil {
+			return err
+		}
+
+		if response.SurveyId != surveyId {
+			return fmt.Errorf("%w: response %s isn't a response to survey %s", auth.ErrForbidden, responseId, surveyId)
+		}
+
+		survey := new(surveys.Survey)
+
+		err = repo.Load(ctx, core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		err = s.authorize(ctx, survey)
+		if err != nil {
+			return err
+		}
+
+		err = checkRespondent(ctx, repo, survey, response, user, cmd.InvitationToken)
+		if err != nil {
+			return err
+		}
+
+		err = response.Submit()
+		if err != nil {
+			return err
+		}
+
+		if s.submissionSaga {
+			err = repo.Save(ctx, response)
+			if err != nil {
+				return err
+			}
+
+			return repo.Save(ctx, surveys.NewSubmissionSaga(response.Id, response.SurveyId))
+		}
+
+		err = survey.ConfirmSlot(response.Id, time.Now())
+		if err != nil {
+			return err
+		}
+
+		err = repo.Save(ctx, response)
+		if err != nil {
+			return err
+		}
+
+		return repo.Save(ctx, survey)
+	})
+}
+
+func (s *SurveyService) authorize(ctx context.Context, survey *surveys.Survey) error {
+	return auth.Authorize(ctx, s.policy, auth.ActionRespond, auth.SurveyResource(*survey))
+}
+
+// checkRespondent checks that the response was started by the caller.
+// Anonymous responses don't record who started them, so knowing their id is
+// what ties them to the respondent.
+func checkRespondent(
+	ctx context.Context,
+	repo core.Repository,
+	survey *surveys.Survey,
+	response *surveys.SurveyResponse,
+	user auth.User,
+	token string,
+) error {
+	invitation, err := loadInvitation(ctx, repo, survey.Id, token)
+	if err != nil {
+		return err
+	}
+
+	if invitation != nil {
+		err = invitation.Verify(token)
+		if err != nil {
+			return err
+		}
+	}
+
+	respondentId, err := survey.RespondentFor(user.Id, invitation)
+	if err != nil {
+		return err
+	}
+
+	if respondentId != response.RespondentId {
+		return fmt.Errorf("%w: response %s belongs to another respondent", auth.ErrForbidden, response.Id)
+	}
+
+	return nil
+}
+
+func newResponse(survey *surveys.Survey, user auth.User, invitation *surveys.Invitation) (*surveys.SurveyResponse, error) {
+	if survey.InvitationRequired && invitation == nil {
+		return nil, surveys.ErrInvitationRequired
+	}
+
+	respondentId, err := survey.RespondentFor(user.Id, invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return surveys.NewSurveyResponse(survey.Id, respondentId), nil
+}
+
+func saveResponse(ctx context.Context, repo core.Repository, response *surveys.SurveyResponse) error {
+	err := repo.Save(ctx, response)
+	if errors.Is(err, core.ErrDuplicateKey) {
+		return surveys.ErrAlreadyResponded
+	}
+
 	return err
 }
+
+// loadInvitation loads the invitation the token was issued for, or returns
+// nil when there's no token.
+func loadInvitation(
+	ctx context.Context,
+	repo core.Repository,
+	surveyId surveys.SurveyId,
+	token string,
+) (*surveys.Invitation, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	invitationId, err := surveys.ParseInvitationToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := new(surveys.Invitation)
+
+	err = repo.Load(ctx, core.AggregateId(invitationId), invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	if invitation.SurveyId != surveyId {
+		return nil, surveys.ErrInvalidInvitationToken
+	}
+
+	return invitation, nil
+}
+
+// redeemInvitation marks the invitation used for the response. Anonymous
+// surveys don't record the response, which would tie it to the invitee.
+func redeemInvitation(
+	ctx context.Context,
+	repo core.Repository,
+	survey *surveys.Survey,
+	invitation *surveys.Invitation,
+	token string,
+	responseId surveys.SurveyResponseId,
+) error {
+	var err error
+	if survey.AnonymityMode == surveys.Anonymous {
+		err = invitation.RedeemAnonymously(token, time.Now())
+	} else {
+		err = invitation.Redeem(token, responseId, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	return repo.Save(ctx, invitation)
+}
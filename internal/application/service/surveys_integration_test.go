@@ -0,0 +1,96 @@
+package service_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres"
+	"github.com/markusryoti/survey-ddd/internal/adapters/postgres/postgrestest"
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
+	"github.com/markusryoti/survey-ddd/internal/application/service"
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurveyServiceIntegration(t *testing.T) {
+	db := postgrestest.New(t)
+
+	registry := core.NewEventRegistry()
+	surveys.RegisterEvents(registry)
+
+	tx := postgres.NewPostgresTransactionalProvider(db, registry, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	srv := service.NewSurveyService(tx, auth.NewRolePolicy())
+
+	survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+	_ = survey.SetAnonymityMode(surveys.Identified)
+	_ = survey.SetEndTime(time.Now().Add(time.Hour))
+	_ = survey.SetMaxParticipants(3)
+	_ = survey.Release(time.Now())
+
+	first, firstToken, _ := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
+	second, secondToken, _ := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
+
+	err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+		for _, aggregate := range []core.Aggregate{survey, first, second} {
+			if err := repo.Save(context.Background(), aggregate); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+
+	ctx := auth.WithUser(context.Background(), auth.User{Id: "respondent", TenantId: "tenant", Roles: []auth.Role{auth.RoleRespondent}})
+
+	t.Run("response, invitation and survey commit together", func(t *testing.T) {
+		err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{
+			SurveyId:        survey.Id.String(),
+			InvitationToken: firstToken,
+		})
+		assert.Nil(t, err)
+
+		loadedSurvey, invitation := load(t, tx, survey.Id, first.Id)
+		assert.Equal(t, 1, loadedSurvey.AnswersReceived())
+		assert.Equal(t, surveys.InvitationStatusRedeemed, invitation.Status(time.Now()))
+	})
+
+	t.Run("a failing response rolls back the redeemed invitation", func(t *testing.T) {
+		// The invitation is redeemed before the second response of the
+		// respondent is rejected.
+		err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{
+			SurveyId:        survey.Id.String(),
+			InvitationToken: secondToken,
+		})
+		assert.ErrorIs(t, err, surveys.ErrAlreadyResponded)
+
+		loadedSurvey, invitation := load(t, tx, survey.Id, second.Id)
+		assert.Equal(t, 1, loadedSurvey.AnswersReceived())
+		assert.Equal(t, surveys.InvitationStatusPending, invitation.Status(time.Now()))
+	})
+}
+
+func load(
+	t *testing.T,
+	tx core.TransactionProvider,
+	surveyId surveys.SurveyId,
+	invitationId surveys.InvitationId,
+) (*surveys.Survey, *surveys.Invitation) {
+	survey := new(surveys.Survey)
+	invitation := new(surveys.Invitation)
+
+	err := tx.RunTransactional(context.Background(), func(repo core.Repository) error {
+		err := repo.Load(context.Background(), core.AggregateId(surveyId), survey)
+		if err != nil {
+			return err
+		}
+
+		return repo.Load(context.Background(), core.AggregateId(invitationId), invitation)
+	})
+	assert.Nil(t, err)
+
+	return survey, invitation
+}
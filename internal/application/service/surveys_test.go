@@ -2,8 +2,15 @@ package service_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/markusryoti/survey-ddd/internal/application/auth"
 	"github.com/markusryoti/survey-ddd/internal/application/service"
 	"github.com/markusryoti/survey-ddd/internal/core"
 	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
@@ -12,53 +19,575 @@ import (
 
 func TestSubmitResponse(t *testing.T) {
 	t.Run("can submit a response to question", func(t *testing.T) {
-		ctx := context.Background()
+		ctx := respondentContext("respondent")
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 5)
 
-		repo := newMockRepo()
-		transctional := newMockTransactionalProvider()
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
 
-		description := "survey description"
+		err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{
+			SurveyId: survey.Id.String(),
+		})
+		assert.Nil(t, err)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 1, loaded.AnswersReceived())
+	})
+}
+
+func TestConcurrentSubmissions(t *testing.T) {
+	const maxParticipants = 5
+	const respondents = 50
+
+	t.Run("started and submitted responses never exceed max participants", func(t *testing.T) {
+		ctx := respondentContext("respondent")
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, maxParticipants)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		var wg sync.WaitGroup
+		var submitted atomic.Int64
+		errs := newErrorCollector()
+
+		for range respondents {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				responseId, err := srv.StartResponse(ctx, service.StartResponseCmd{
+					SurveyId: survey.Id.String(),
+				})
+				if err != nil {
+					errs.add(err)
+					return
+				}
+
+				err = srv.SubmitResponse(ctx, service.SubmitResponseCmd{
+					SurveyId:   survey.Id.String(),
+					ResponseId: responseId.String(),
+				})
+				if err != nil {
+					errs.add(err)
+					return
+				}
+
+				submitted.Add(1)
+			}()
+		}
+
+		wg.Wait()
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.LessOrEqual(t, loaded.AnswersReceived(), maxParticipants)
+		assert.Equal(t, int(submitted.Load()), loaded.AnswersReceived())
+		assert.Empty(t, errs.unexpected())
+	})
+
+	t.Run("direct submissions never exceed max participants", func(t *testing.T) {
+		ctx := respondentContext("respondent")
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, maxParticipants)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		var wg sync.WaitGroup
+		var submitted atomic.Int64
+		errs := newErrorCollector()
+
+		for range respondents {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
 
-		survey, err := surveys.NewSurvey("some title", &description, "tenant")
+				err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{
+					SurveyId: survey.Id.String(),
+				})
+				if err != nil {
+					errs.add(err)
+					return
+				}
+
+				submitted.Add(1)
+			}()
+		}
+
+		wg.Wait()
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.LessOrEqual(t, loaded.AnswersReceived(), maxParticipants)
+		assert.Equal(t, int(submitted.Load()), loaded.AnswersReceived())
+		assert.Empty(t, errs.unexpected())
+	})
+}
+
+func TestIdentifiedRespondents(t *testing.T) {
+	t.Run("same respondent can't respond twice", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+		_ = survey.SetAnonymityMode(surveys.Identified)
+		_ = survey.SetEndTime(time.Now().Add(time.Hour))
+		_ = survey.SetMaxParticipants(3)
+		_ = survey.Release(time.Now())
+		store.seed(t, survey)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		ctx := respondentContext("respondent")
+
+		err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{SurveyId: survey.Id.String()})
 		assert.Nil(t, err)
 
-		err = repo.Save(ctx, survey)
+		err = srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{SurveyId: survey.Id.String()})
+		assert.ErrorIs(t, err, surveys.ErrAlreadyResponded)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 1, loaded.AnswersReceived())
+	})
+}
+
+func TestPseudonymousRespondents(t *testing.T) {
+	newPseudonymousSurvey := func(t *testing.T, store *memoryTransactionalProvider) *surveys.Survey {
+		survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+		_ = survey.SetAnonymityMode(surveys.Pseudonymous)
+		_ = survey.SetEndTime(time.Now().Add(time.Hour))
+		_ = survey.SetMaxParticipants(3)
+		_ = survey.Release(time.Now())
+		store.seed(t, survey)
+
+		return survey
+	}
+
+	t.Run("respondents need an invitation", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := newPseudonymousSurvey(t, store)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		err := srv.AddResponseToQuestion(respondentContext("respondent"), service.ResponseToSurveyCmd{SurveyId: survey.Id.String()})
+		assert.NotNil(t, err)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 0, loaded.AnswersReceived())
+	})
+
+	t.Run("an invitation can't be used to respond twice", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := newPseudonymousSurvey(t, store)
+
+		invitation, token, err := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
 		assert.Nil(t, err)
-		assert.NotEqual(t, "", survey.Id.String())
+		store.seed(t, invitation)
 
-		srv := service.NewSurveyService(repo, transctional)
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+		cmd := service.ResponseToSurveyCmd{SurveyId: survey.Id.String(), InvitationToken: token}
 
-		err = srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{
-			SurveyId: survey.Id.String(),
+		err = srv.AddResponseToQuestion(respondentContext("respondent"), cmd)
+		assert.Nil(t, err)
+		assert.True(t, store.loadInvitation(t, invitation.Id).Linked())
+
+		err = srv.AddResponseToQuestion(respondentContext("respondent"), cmd)
+		assert.NotNil(t, err)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 1, loaded.AnswersReceived())
+	})
+}
+
+func TestInvitationRequired(t *testing.T) {
+	newInvitationOnlySurvey := func(t *testing.T, store *memoryTransactionalProvider) *surveys.Survey {
+		survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+		_ = survey.RequireInvitation(true)
+		_ = survey.SetEndTime(time.Now().Add(time.Hour))
+		_ = survey.SetMaxParticipants(3)
+		_ = survey.Release(time.Now())
+		store.seed(t, survey)
+
+		return survey
+	}
+
+	t.Run("rejects responses without an invitation", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := newInvitationOnlySurvey(t, store)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		err := srv.AddResponseToQuestion(respondentContext("respondent"), service.ResponseToSurveyCmd{SurveyId: survey.Id.String()})
+		assert.ErrorIs(t, err, surveys.ErrInvitationRequired)
+
+		_, err = srv.StartResponse(respondentContext("respondent"), service.StartResponseCmd{SurveyId: survey.Id.String()})
+		assert.ErrorIs(t, err, surveys.ErrInvitationRequired)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 0, loaded.AnswersReceived())
+	})
+
+	t.Run("accepts responses with an invitation", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := newInvitationOnlySurvey(t, store)
+
+		invitation, token, err := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
+		assert.Nil(t, err)
+		store.seed(t, invitation)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		err = srv.AddResponseToQuestion(respondentContext("respondent"), service.ResponseToSurveyCmd{
+			SurveyId:        survey.Id.String(),
+			InvitationToken: token,
 		})
 		assert.Nil(t, err)
 
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 1, loaded.AnswersReceived())
+	})
+
+	t.Run("invitations to anonymous surveys aren't tied to their response", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := newInvitationOnlySurvey(t, store)
+		assert.Equal(t, surveys.Anonymous, survey.AnonymityMode)
+
+		invitation, token, err := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
+		assert.Nil(t, err)
+		store.seed(t, invitation)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		_, err = srv.StartResponse(respondentContext("respondent"), service.StartResponseCmd{
+			SurveyId:        survey.Id.String(),
+			InvitationToken: token,
+		})
+		assert.Nil(t, err)
+
+		redeemed := store.loadInvitation(t, invitation.Id)
+		assert.Equal(t, surveys.InvitationStatusRedeemed, redeemed.Status(time.Now()))
+		assert.False(t, redeemed.Linked())
+		assert.True(t, redeemed.RedeemedAt.IsZero())
 	})
 }
 
-type mockRepo struct {
+func TestResponseAuthorization(t *testing.T) {
+	newIdentifiedSurvey := func(t *testing.T, store *memoryTransactionalProvider) *surveys.Survey {
+		survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+		_ = survey.SetAnonymityMode(surveys.Identified)
+		_ = survey.SetEndTime(time.Now().Add(time.Hour))
+		_ = survey.SetMaxParticipants(3)
+		_ = survey.Release(time.Now())
+		store.seed(t, survey)
+
+		return survey
+	}
+
+	t.Run("users without the respondent role can't respond", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 3)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+		ctx := auth.WithUser(context.Background(), auth.User{Id: "analyst", TenantId: "tenant", Roles: []auth.Role{auth.RoleAnalyst}})
+
+		err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{SurveyId: survey.Id.String()})
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+
+		_, err = srv.StartResponse(ctx, service.StartResponseCmd{SurveyId: survey.Id.String()})
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+
+		loaded := store.loadSurvey(t, survey.Id)
+		assert.Equal(t, 0, loaded.AnswersReceived())
+	})
+
+	t.Run("respondents of another tenant can't respond", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 3)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+		ctx := auth.WithUser(context.Background(), auth.User{Id: "respondent", TenantId: "other", Roles: []auth.Role{auth.RoleRespondent}})
+
+		err := srv.AddResponseToQuestion(ctx, service.ResponseToSurveyCmd{SurveyId: survey.Id.String()})
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("respondents can't submit responses of others", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := newIdentifiedSurvey(t, store)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+
+		responseId, err := srv.StartResponse(respondentContext("first"), service.StartResponseCmd{SurveyId: survey.Id.String()})
+		assert.Nil(t, err)
+
+		cmd := service.SubmitResponseCmd{SurveyId: survey.Id.String(), ResponseId: responseId.String()}
+
+		err = srv.SubmitResponse(respondentContext("second"), cmd)
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+
+		err = srv.SubmitResponse(respondentContext("first"), cmd)
+		assert.Nil(t, err)
+	})
+
+	t.Run("responses are submitted to their own survey", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey := seedReleasedSurvey(t, store, 3)
+		other := seedReleasedSurvey(t, store, 3)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+		ctx := respondentContext("respondent")
+
+		responseId, err := srv.StartResponse(ctx, service.StartResponseCmd{SurveyId: survey.Id.String()})
+		assert.Nil(t, err)
+
+		err = srv.SubmitResponse(ctx, service.SubmitResponseCmd{SurveyId: other.Id.String(), ResponseId: responseId.String()})
+		assert.ErrorIs(t, err, auth.ErrForbidden)
+	})
+
+	t.Run("pseudonymous respondents submit with their invitation", func(t *testing.T) {
+		store := newMemoryTransactionalProvider()
+		survey, _ := surveys.NewSurvey("some title", nil, "tenant", "owner")
+		_ = survey.SetAnonymityMode(surveys.Pseudonymous)
+		_ = survey.SetEndTime(time.Now().Add(time.Hour))
+		_ = survey.SetMaxParticipants(3)
+		_ = survey.Release(time.Now())
+		store.seed(t, survey)
+
+		first, firstToken, _ := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
+		_, secondToken, _ := surveys.NewInvitation(survey.Id, time.Now().Add(time.Hour), time.Now())
+		store.seed(t, first)
+
+		srv := service.NewSurveyService(store, auth.NewRolePolicy())
+		ctx := respondentContext("respondent")
+
+		responseId, err := srv.StartResponse(ctx, service.StartResponseCmd{SurveyId: survey.Id.String(), InvitationToken: firstToken})
+		assert.Nil(t, err)
+
+		cmd := service.SubmitResponseCmd{SurveyId: survey.Id.String(), ResponseId: responseId.String()}
+
+		err = srv.SubmitResponse(ctx, cmd)
+		assert.NotNil(t, err)
+
+		forged := first.Id.String() + "." + strings.SplitN(secondToken, ".", 2)[1]
+		cmd.InvitationToken = forged
+		err = srv.SubmitResponse(ctx, cmd)
+		assert.ErrorIs(t, err, surveys.ErrInvalidInvitationToken)
+
+		cmd.InvitationToken = firstToken
+		err = srv.SubmitResponse(ctx, cmd)
+		assert.Nil(t, err)
+	})
+}
+
+func seedReleasedSurvey(t *testing.T, store *memoryTransactionalProvider, maxParticipants int) *surveys.Survey {
+	survey, err := surveys.NewSurvey("some title", nil, "tenant", "owner")
+	assert.Nil(t, err)
+
+	_ = survey.SetEndTime(time.Now().Add(time.Hour))
+	_ = survey.SetMaxParticipants(maxParticipants)
+	_ = survey.Release(time.Now())
+
+	store.seed(t, survey)
+
+	return survey
+}
+
+// respondentContext returns a context of a respondent of the tenant the
+// seeded surveys belong to.
+func respondentContext(userId string) context.Context {
+	return auth.WithUser(context.Background(), auth.User{Id: userId, TenantId: "tenant", Roles: []auth.Role{auth.RoleRespondent}})
+}
+
+type errorCollector struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func newErrorCollector() *errorCollector {
+	return &errorCollector{}
+}
+
+func (c *errorCollector) add(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.errs = append(c.errs, err)
+}
+
+// unexpected returns errors other than running out of slots or running out
+// of conflict retries.
+func (c *errorCollector) unexpected() []error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var res []error
+	for _, err := range c.errs {
+		if errors.Is(err, surveys.ErrNoSlotsAvailable) {
+			continue
+		}
+		if errors.Is(err, core.ErrConcurrencyConflict) {
+			continue
+		}
+		res = append(res, err)
+	}
+
+	return res
 }
 
-func newMockRepo() *mockRepo {
-	return &mockRepo{}
+// memoryTransactionalProvider keeps aggregates in memory and applies the
+// writes of a transaction atomically on commit, rejecting them if another
+// transaction has changed any of the aggregates in the meantime.
+type memoryTransactionalProvider struct {
+	mu   sync.Mutex
+	rows map[memoryKey]memoryRow
+	keys map[core.UniqueKey]core.AggregateId
 }
 
-func (r *mockRepo) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+// memoryKey identifies a row like the table and id of a Postgres row do.
+type memoryKey struct {
+	table string
+	id    core.AggregateId
+}
+
+type memoryRow struct {
+	data    []byte
+	version int
+}
+
+type memoryWrite struct {
+	key      memoryKey
+	data     []byte
+	expected int
+	keys     []core.UniqueKey
+}
+
+func newMemoryTransactionalProvider() *memoryTransactionalProvider {
+	return &memoryTransactionalProvider{
+		rows: make(map[memoryKey]memoryRow),
+		keys: make(map[core.UniqueKey]core.AggregateId),
+	}
+}
+
+func (p *memoryTransactionalProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	tx := &memoryTx{provider: p}
+
+	err := fn(tx)
+	if err != nil {
+		return err
+	}
+
+	return p.commit(tx.writes)
+}
+
+func (p *memoryTransactionalProvider) commit(writes []memoryWrite) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, w := range writes {
+		if p.rows[w.key].version != w.expected {
+			return core.ErrConcurrencyConflict
+		}
+
+		for _, key := range w.keys {
+			if _, ok := p.keys[key]; ok {
+				return core.ErrDuplicateKey
+			}
+		}
+	}
+
+	for _, w := range writes {
+		p.rows[w.key] = memoryRow{data: w.data, version: w.expected + 1}
+
+		for _, key := range w.keys {
+			p.keys[key] = w.key.id
+		}
+	}
+
 	return nil
 }
 
-func (r *mockRepo) Save(ctx context.Context, aggregate core.Aggregate) error {
+func (p *memoryTransactionalProvider) seed(t *testing.T, aggregate core.Aggregate) {
+	err := p.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Save(context.Background(), aggregate)
+	})
+	assert.Nil(t, err)
+}
+
+func (p *memoryTransactionalProvider) loadSurvey(t *testing.T, id surveys.SurveyId) *surveys.Survey {
+	survey := new(surveys.Survey)
+
+	err := p.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Load(context.Background(), core.AggregateId(id), survey)
+	})
+	assert.Nil(t, err)
+
+	return survey
+}
+
+func (p *memoryTransactionalProvider) loadInvitation(t *testing.T, id surveys.InvitationId) *surveys.Invitation {
+	invitation := new(surveys.Invitation)
+
+	err := p.RunTransactional(context.Background(), func(repo core.Repository) error {
+		return repo.Load(context.Background(), core.AggregateId(id), invitation)
+	})
+	assert.Nil(t, err)
+
+	return invitation
+}
+
+type memoryTx struct {
+	provider *memoryTransactionalProvider
+	writes   []memoryWrite
+}
+
+func (tx *memoryTx) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	tx.provider.mu.Lock()
+	row, ok := tx.provider.rows[memoryKey{table: aggregate.TableName(), id: id}]
+	tx.provider.mu.Unlock()
+
+	if !ok {
+		return errors.New("aggregate not found")
+	}
+
+	err := json.Unmarshal(row.data, aggregate)
+	if err != nil {
+		return err
+	}
+
+	aggregate.SetVersion(row.version)
 
 	return nil
 }
 
-type mockTransactionalProvider struct {
+// The memory store keeps snapshots only, so past states can't be rebuilt.
+func (tx *memoryTx) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return errors.New("not supported")
 }
 
-func newMockTransactionalProvider() *mockTransactionalProvider {
-	return &mockTransactionalProvider{}
+func (tx *memoryTx) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return errors.New("not supported")
 }
 
-func (t *mockTransactionalProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+func (tx *memoryTx) Save(ctx context.Context, aggregate core.Aggregate) error {
+	data, err := json.Marshal(aggregate)
+	if err != nil {
+		return err
+	}
+
+	w := memoryWrite{
+		key:      memoryKey{table: aggregate.TableName(), id: aggregate.ID()},
+		data:     data,
+		expected: aggregate.Version(),
+	}
+
+	if holder, ok := aggregate.(core.UniqueKeyHolder); ok && aggregate.Version() == 0 {
+		w.keys = holder.UniqueKeys()
+
+		tx.provider.mu.Lock()
+		defer tx.provider.mu.Unlock()
+
+		for _, key := range w.keys {
+			if _, ok := tx.provider.keys[key]; ok {
+				return core.ErrDuplicateKey
+			}
+		}
+	}
+
+	tx.writes = append(tx.writes, w)
+
 	return nil
 }
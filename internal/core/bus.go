@@ -0,0 +1,98 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrUnknownCommand = errors.New("no handler registered for command")
+
+// Command is a request to change the state of the system.
+type Command interface {
+	CommandName() string
+}
+
+type CommandHandlerFunc func(ctx context.Context, cmd Command) (any, error)
+
+// CommandMiddleware wraps the handling of every dispatched command.
+type CommandMiddleware func(next CommandHandlerFunc) CommandHandlerFunc
+
+// CommandBus dispatches commands to the handler registered for them, passing
+// them through the middleware chain first. Middleware is applied in the
+// order it was added, the first one being the outermost.
+type CommandBus struct {
+	handlers   map[string]CommandHandlerFunc
+	middleware []CommandMiddleware
+}
+
+func NewCommandBus(middleware ...CommandMiddleware) *CommandBus {
+	return &CommandBus{
+		handlers:   make(map[string]CommandHandlerFunc),
+		middleware: middleware,
+	}
+}
+
+func (b *CommandBus) Use(middleware ...CommandMiddleware) {
+	b.middleware = append(b.middleware, middleware...)
+}
+
+func (b *CommandBus) Register(name string, handler CommandHandlerFunc) {
+	if _, ok := b.handlers[name]; ok {
+		panic(fmt.Sprintf("handler already registered for command %s", name))
+	}
+
+	b.handlers[name] = handler
+}
+
+func (b *CommandBus) Dispatch(ctx context.Context, cmd Command) (any, error) {
+	handler, ok := b.handlers[cmd.CommandName()]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownCommand, cmd.CommandName())
+	}
+
+	for i := len(b.middleware) - 1; i >= 0; i-- {
+		handler = b.middleware[i](handler)
+	}
+
+	return handler(ctx, cmd)
+}
+
+// Handle registers a handler producing a result for commands of type C.
+func Handle[C Command, R any](bus *CommandBus, handler func(ctx context.Context, cmd C) (R, error)) {
+	var zero C
+
+	bus.Register(zero.CommandName(), func(ctx context.Context, cmd Command) (any, error) {
+		c, ok := cmd.(C)
+		if !ok {
+			return nil, fmt.Errorf("unexpected command type %T for %s", cmd, zero.CommandName())
+		}
+
+		return handler(ctx, c)
+	})
+}
+
+// HandleFunc registers a handler for commands of type C that only reports
+// success or failure.
+func HandleFunc[C Command](bus *CommandBus, handler func(ctx context.Context, cmd C) error) {
+	Handle(bus, func(ctx context.Context, cmd C) (struct{}, error) {
+		return struct{}{}, handler(ctx, cmd)
+	})
+}
+
+// Dispatch sends the command through the bus and returns its typed result.
+func Dispatch[R any](ctx context.Context, bus *CommandBus, cmd Command) (R, error) {
+	var zero R
+
+	res, err := bus.Dispatch(ctx, cmd)
+	if err != nil {
+		return zero, err
+	}
+
+	r, ok := res.(R)
+	if !ok {
+		return zero, fmt.Errorf("unexpected result type %T for %s", res, cmd.CommandName())
+	}
+
+	return r, nil
+}
@@ -0,0 +1,95 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Validator is implemented by commands that can check their own input
+// before they are handled.
+type Validator interface {
+	Validate() error
+}
+
+func ValidationMiddleware() CommandMiddleware {
+	return func(next CommandHandlerFunc) CommandHandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			if v, ok := cmd.(Validator); ok {
+				if err := v.Validate(); err != nil {
+					return nil, err
+				}
+			}
+
+			return next(ctx, cmd)
+		}
+	}
+}
+
+// RetryMiddleware handles the command again when it fails on a concurrency
+// conflict.
+func RetryMiddleware(policy RetryPolicy) CommandMiddleware {
+	return func(next CommandHandlerFunc) CommandHandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			var res any
+
+			err := RetryOnConflict(ctx, policy, func() error {
+				var err error
+				res, err = next(ctx, cmd)
+				return err
+			})
+
+			return res, err
+		}
+	}
+}
+
+func LoggingMiddleware(logger *slog.Logger) CommandMiddleware {
+	return func(next CommandHandlerFunc) CommandHandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			start := time.Now()
+
+			res, err := next(ctx, cmd)
+
+			attrs := []slog.Attr{
+				slog.String("command", cmd.CommandName()),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if err != nil {
+				attrs = append(attrs, slog.String("error", err.Error()))
+				logger.LogAttrs(ctx, slog.LevelWarn, "command failed", attrs...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelInfo, "command handled", attrs...)
+			}
+
+			return res, err
+		}
+	}
+}
+
+// CommandObserver is notified around every dispatched command, for example
+// to record metrics or tracing spans. The returned context is passed on to
+// the handler and the returned function is called with the outcome.
+type CommandObserver interface {
+	ObserveCommand(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+// QueryObserver is notified around every query in the same way. Queries
+// aren't dispatched through a bus, so query handlers call it themselves.
+type QueryObserver interface {
+	ObserveQuery(ctx context.Context, name string) (context.Context, func(err error))
+}
+
+func ObserverMiddleware(observer CommandObserver) CommandMiddleware {
+	return func(next CommandHandlerFunc) CommandHandlerFunc {
+		return func(ctx context.Context, cmd Command) (any, error) {
+			ctx, done := observer.ObserveCommand(ctx, cmd.CommandName())
+
+			res, err := next(ctx, cmd)
+			done(err)
+
+			return res, err
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommandBus(t *testing.T) {
+	t.Run("dispatches to the registered handler", func(t *testing.T) {
+		bus := core.NewCommandBus()
+		core.Handle(bus, func(ctx context.Context, cmd greetCommand) (string, error) {
+			return "hello " + cmd.Name, nil
+		})
+
+		res, err := core.Dispatch[string](context.Background(), bus, greetCommand{Name: "world"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, "hello world", res)
+	})
+
+	t.Run("fails on unknown commands", func(t *testing.T) {
+		bus := core.NewCommandBus()
+
+		_, err := bus.Dispatch(context.Background(), greetCommand{Name: "world"})
+
+		assert.ErrorIs(t, err, core.ErrUnknownCommand)
+	})
+
+	t.Run("can't register a command twice", func(t *testing.T) {
+		bus := core.NewCommandBus()
+		core.HandleFunc(bus, func(ctx context.Context, cmd greetCommand) error { return nil })
+
+		assert.Panics(t, func() {
+			core.HandleFunc(bus, func(ctx context.Context, cmd greetCommand) error { return nil })
+		})
+	})
+
+	t.Run("applies middleware in order", func(t *testing.T) {
+		var calls []string
+
+		record := func(name string) core.CommandMiddleware {
+			return func(next core.CommandHandlerFunc) core.CommandHandlerFunc {
+				return func(ctx context.Context, cmd core.Command) (any, error) {
+					calls = append(calls, name)
+					return next(ctx, cmd)
+				}
+			}
+		}
+
+		bus := core.NewCommandBus(record("first"), record("second"))
+		core.HandleFunc(bus, func(ctx context.Context, cmd greetCommand) error {
+			calls = append(calls, "handler")
+			return nil
+		})
+
+		_, err := bus.Dispatch(context.Background(), greetCommand{Name: "world"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"first", "second", "handler"}, calls)
+	})
+
+	t.Run("validates commands before handling", func(t *testing.T) {
+		handled := false
+
+		bus := core.NewCommandBus(core.ValidationMiddleware())
+		core.HandleFunc(bus, func(ctx context.Context, cmd greetCommand) error {
+			handled = true
+			return nil
+		})
+
+		_, err := bus.Dispatch(context.Background(), greetCommand{})
+
+		assert.ErrorIs(t, err, errNameRequired)
+		assert.False(t, handled)
+	})
+
+	t.Run("retries commands on concurrency conflicts", func(t *testing.T) {
+		attempts := 0
+
+		bus := core.NewCommandBus(core.RetryMiddleware(core.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Millisecond,
+			MaxDelay:    5 * time.Millisecond,
+		}))
+		core.HandleFunc(bus, func(ctx context.Context, cmd greetCommand) error {
+			attempts++
+			if attempts < 3 {
+				return core.ErrConcurrencyConflict
+			}
+			return nil
+		})
+
+		_, err := bus.Dispatch(context.Background(), greetCommand{Name: "world"})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 3, attempts)
+	})
+}
+
+var errNameRequired = errors.New("name is required")
+
+type greetCommand struct {
+	Name string
+}
+
+func (c greetCommand) CommandName() string {
+	return "greet"
+}
+
+func (c greetCommand) Validate() error {
+	if c.Name == "" {
+		return errNameRequired
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+)
+
+var ErrContextTransactionsUnsupported = errors.New("transaction provider does not support context transactions")
+
+// EventHandler reacts to an event after the transaction that stored it has
+// been committed.
+type EventHandler func(ctx context.Context, event DomainEvent) error
+
+// TransactionalEventHandler reacts to an event inside the transaction that
+// stores it. The repository belongs to that transaction, so whatever the
+// handler saves commits or rolls back together with the event.
+type TransactionalEventHandler func(ctx context.Context, repo Repository, event DomainEvent) error
+
+// EventBus delivers domain events to the handlers subscribed to their type.
+//
+// Handlers run after commit are isolated from each other: an error or a
+// panic in one of them is logged and doesn't prevent the rest from running.
+// Handlers run before commit are part of the transaction, and their errors
+// abort it.
+type EventBus struct {
+	mu           sync.RWMutex
+	afterCommit  map[reflect.Type][]EventHandler
+	beforeCommit map[reflect.Type][]TransactionalEventHandler
+	logger       *slog.Logger
+}
+
+func NewEventBus(logger *slog.Logger) *EventBus {
+	return &EventBus{
+		afterCommit:  make(map[reflect.Type][]EventHandler),
+		beforeCommit: make(map[reflect.Type][]TransactionalEventHandler),
+		logger:       logger,
+	}
+}
+
+// Subscribe registers a handler for events of type E run after commit.
+func Subscribe[E DomainEvent](bus *EventBus, handler func(ctx context.Context, event E) error) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	t := reflect.TypeFor[E]()
+	bus.afterCommit[t] = append(bus.afterCommit[t], func(ctx context.Context, event DomainEvent) error {
+		return handler(ctx, event.(E))
+	})
+}
+
+// SubscribeBeforeCommit registers a handler for events of type E run in the
+// transaction that stores them.
+func SubscribeBeforeCommit[E DomainEvent](bus *EventBus, handler func(ctx context.Context, repo Repository, event E) error) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	t := reflect.TypeFor[E]()
+	bus.beforeCommit[t] = append(bus.beforeCommit[t], func(ctx context.Context, repo Repository, event DomainEvent) error {
+		return handler(ctx, repo, event.(E))
+	})
+}
+
+// Publish runs the after commit handlers of every event. Failing handlers
+// are logged and skipped. Handlers inherit the metadata of the context, with
+// the event as the causation.
+func (b *EventBus) Publish(ctx context.Context, events ...RecordedEvent) {
+	for _, event := range events {
+		b.mu.RLock()
+		handlers := b.afterCommit[reflect.TypeOf(event.Event)]
+		b.mu.RUnlock()
+
+		handlerCtx := WithMetadata(ctx, Metadata{CausationId: EventCausationId(event)})
+
+		for _, handler := range handlers {
+			err := safely(func() error {
+				return handler(handlerCtx, event.Event)
+			})
+			if err != nil {
+				b.logger.ErrorContext(ctx, "event handler failed",
+					slog.String("event", event.Event.Type()),
+					slog.String("aggregateId", event.Event.AggregateId().String()),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// publishBeforeCommit runs the before commit handlers of the event and
+// returns the first error.
+func (b *EventBus) publishBeforeCommit(ctx context.Context, repo Repository, event RecordedEvent) error {
+	b.mu.RLock()
+	handlers := b.beforeCommit[reflect.TypeOf(event.Event)]
+	b.mu.RUnlock()
+
+	ctx = WithMetadata(ctx, Metadata{CausationId: EventCausationId(event)})
+
+	for _, handler := range handlers {
+		err := safely(func() error {
+			return handler(ctx, repo, event.Event)
+		})
+		if err != nil {
+			return fmt.Errorf("handling %s: %w", event.Event.Type(), err)
+		}
+	}
+
+	return nil
+}
+
+func safely(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return fn()
+}
+
+// recordingRepository remembers the events of every aggregate saved through
+// it. Repositories give every event the next version of its aggregate.
+type recordingRepository struct {
+	Repository
+	events []RecordedEvent
+}
+
+func (r *recordingRepository) Save(ctx context.Context, aggregate Aggregate) error {
+	events := aggregate.GetUncommittedEvents()
+	version := aggregate.Version()
+
+	err := r.Repository.Save(ctx, aggregate)
+	if err != nil {
+		return err
+	}
+
+	for i, event := range events {
+		r.events = append(r.events, RecordedEvent{Event: event, Version: version + i + 1})
+	}
+
+	return nil
+}
+
+type pendingEventsKey struct{}
+
+// pendingEvents collects the events of transactions joining a transaction
+// carried in the context, to be published once that one commits.
+type pendingEvents struct {
+	mu     sync.Mutex
+	events []RecordedEvent
+}
+
+func (p *pendingEvents) add(events []RecordedEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, events...)
+}
+
+func (p *pendingEvents) reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = nil
+}
+
+// PublishingTransactionProvider hands the events saved in a transaction to
+// the event bus: before commit handlers run inside the transaction and after
+// commit handlers once it has been committed.
+type PublishingTransactionProvider struct {
+	next TransactionProvider
+	bus  *EventBus
+}
+
+func NewPublishingTransactionProvider(next TransactionProvider, bus *EventBus) *PublishingTransactionProvider {
+	return &PublishingTransactionProvider{
+		next: next,
+		bus:  bus,
+	}
+}
+
+func (p *PublishingTransactionProvider) RunTransactional(ctx context.Context, fn TransactionSignature) error {
+	var events []RecordedEvent
+
+	err := p.next.RunTransactional(ctx, func(repo Repository) error {
+		recorder := &recordingRepository{Repository: repo}
+
+		err := fn(recorder)
+		if err != nil {
+			return err
+		}
+
+		// Handlers may save aggregates too, their events are handled in turn.
+		for i := 0; i < len(recorder.events); i++ {
+			err = p.bus.publishBeforeCommit(ctx, recorder, recorder.events[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		events = recorder.events
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if pending, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents); ok {
+		pending.add(events)
+		return nil
+	}
+
+	p.bus.Publish(ctx, events...)
+
+	return nil
+}
+
+// WithTransaction runs fn in a transaction carried in the context, and
+// publishes the events of every transaction joining it after it commits.
+func (p *PublishingTransactionProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	next, ok := p.next.(ContextTransactionProvider)
+	if !ok {
+		return ErrContextTransactionsUnsupported
+	}
+
+	if _, ok := ctx.Value(pendingEventsKey{}).(*pendingEvents); ok {
+		return next.WithTransaction(ctx, fn)
+	}
+
+	pending := &pendingEvents{}
+
+	err := next.WithTransaction(context.WithValue(ctx, pendingEventsKey{}, pending), func(ctx context.Context) error {
+		// The transaction may be run again, dropping the events of the
+		// attempts that were rolled back.
+		pending.reset()
+		return fn(ctx)
+	})
+	if err != nil {
+		return err
+	}
+
+	p.bus.Publish(ctx, pending.events...)
+
+	return nil
+}
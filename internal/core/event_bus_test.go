@@ -0,0 +1,321 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventBus(t *testing.T) {
+	t.Run("delivers events to subscribers of their type", func(t *testing.T) {
+		bus := newEventBus()
+
+		var pings, pongs int
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			pings++
+			return nil
+		})
+		core.Subscribe(bus, func(ctx context.Context, event ponged) error {
+			pongs++
+			return nil
+		})
+
+		bus.Publish(context.Background(), recorded(pinged{}), recorded(pinged{}), recorded(ponged{}))
+
+		assert.Equal(t, 2, pings)
+		assert.Equal(t, 1, pongs)
+	})
+
+	t.Run("isolates failing handlers", func(t *testing.T) {
+		bus := newEventBus()
+
+		handled := 0
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			return errors.New("boom")
+		})
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			panic("boom")
+		})
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			handled++
+			return nil
+		})
+
+		bus.Publish(context.Background(), recorded(pinged{}))
+
+		assert.Equal(t, 1, handled)
+	})
+
+	t.Run("handlers are caused by the event", func(t *testing.T) {
+		bus := newEventBus()
+
+		var metadata core.Metadata
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			metadata = core.MetadataFromContext(ctx)
+			return nil
+		})
+
+		event := core.RecordedEvent{Event: pinged{Id: core.NewAggregateId()}, Version: 1}
+		ctx := core.WithMetadata(context.Background(), core.Metadata{
+			ActorId:       "user",
+			CorrelationId: "correlation",
+			CausationId:   "request",
+		})
+
+		bus.Publish(ctx, event)
+
+		assert.Equal(t, "user", metadata.ActorId)
+		assert.Equal(t, "correlation", metadata.CorrelationId)
+		assert.Equal(t, core.EventCausationId(event), metadata.CausationId)
+	})
+
+	t.Run("events of the same type have their own causation ids", func(t *testing.T) {
+		bus := newEventBus()
+		provider := core.NewPublishingTransactionProvider(&recordingTransactionProvider{}, bus)
+
+		var causationIds []string
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			causationIds = append(causationIds, core.MetadataFromContext(ctx).CausationId)
+			return nil
+		})
+
+		aggregate := newPingAggregate()
+		aggregate.SetVersion(3)
+		aggregate.AddDomainEvent(pinged{Id: aggregate.Id})
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), aggregate)
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{
+			aggregate.Id.String() + "/4",
+			aggregate.Id.String() + "/5",
+		}, causationIds)
+	})
+}
+
+func TestPublishingTransactionProvider(t *testing.T) {
+	t.Run("publishes saved events after commit", func(t *testing.T) {
+		bus := newEventBus()
+		tx := &recordingTransactionProvider{}
+		provider := core.NewPublishingTransactionProvider(tx, bus)
+
+		committed := false
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			committed = tx.committed
+			return nil
+		})
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), newPingAggregate())
+		})
+
+		assert.Nil(t, err)
+		assert.True(t, committed)
+	})
+
+	t.Run("doesn't publish events of failed transactions", func(t *testing.T) {
+		bus := newEventBus()
+		provider := core.NewPublishingTransactionProvider(&recordingTransactionProvider{}, bus)
+
+		handled := false
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			handled = true
+			return nil
+		})
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			err := repo.Save(context.Background(), newPingAggregate())
+			assert.Nil(t, err)
+			return errors.New("boom")
+		})
+
+		assert.NotNil(t, err)
+		assert.False(t, handled)
+	})
+
+	t.Run("before commit handlers run in the transaction", func(t *testing.T) {
+		bus := newEventBus()
+		tx := &recordingTransactionProvider{}
+		provider := core.NewPublishingTransactionProvider(tx, bus)
+
+		core.SubscribeBeforeCommit(bus, func(ctx context.Context, repo core.Repository, event pinged) error {
+			assert.False(t, tx.committed)
+			return nil
+		})
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), newPingAggregate())
+		})
+
+		assert.Nil(t, err)
+	})
+
+	t.Run("failing before commit handlers abort the transaction", func(t *testing.T) {
+		bus := newEventBus()
+		tx := &recordingTransactionProvider{}
+		provider := core.NewPublishingTransactionProvider(tx, bus)
+
+		core.SubscribeBeforeCommit(bus, func(ctx context.Context, repo core.Repository, event pinged) error {
+			return errors.New("boom")
+		})
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return repo.Save(context.Background(), newPingAggregate())
+		})
+
+		assert.NotNil(t, err)
+		assert.False(t, tx.committed)
+	})
+
+	t.Run("publishes events of joined transactions when the outer one commits", func(t *testing.T) {
+		bus := newEventBus()
+		tx := &recordingTransactionProvider{}
+		provider := core.NewPublishingTransactionProvider(tx, bus)
+
+		handled := 0
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			handled++
+			return nil
+		})
+
+		err := provider.WithTransaction(context.Background(), func(ctx context.Context) error {
+			for range 2 {
+				err := provider.RunTransactional(ctx, func(repo core.Repository) error {
+					return repo.Save(ctx, newPingAggregate())
+				})
+				assert.Nil(t, err)
+			}
+
+			assert.Equal(t, 0, handled)
+
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 2, handled)
+	})
+
+	t.Run("publishes events of the last run of a retried transaction only", func(t *testing.T) {
+		bus := newEventBus()
+		tx := &recordingTransactionProvider{runs: 2}
+		provider := core.NewPublishingTransactionProvider(tx, bus)
+
+		handled := 0
+		core.Subscribe(bus, func(ctx context.Context, event pinged) error {
+			handled++
+			return nil
+		})
+
+		err := provider.WithTransaction(context.Background(), func(ctx context.Context) error {
+			return provider.RunTransactional(ctx, func(repo core.Repository) error {
+				return repo.Save(ctx, newPingAggregate())
+			})
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 1, handled)
+	})
+}
+
+func newEventBus() *core.EventBus {
+	return core.NewEventBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+type pinged struct {
+	Id core.AggregateId
+}
+
+func (e pinged) AggregateId() core.AggregateId { return e.Id }
+func (e pinged) Type() string                  { return "Pinged" }
+func (e pinged) OccurredAt() time.Time         { return time.Time{} }
+
+type ponged struct {
+	Id core.AggregateId
+}
+
+func (e ponged) AggregateId() core.AggregateId { return e.Id }
+func (e ponged) Type() string                  { return "Ponged" }
+func (e ponged) OccurredAt() time.Time         { return time.Time{} }
+
+func recorded(event core.DomainEvent) core.RecordedEvent {
+	return core.RecordedEvent{Event: event, Version: 1}
+}
+
+type pingAggregate struct {
+	core.BaseAggregate
+	Id core.AggregateId
+}
+
+func newPingAggregate() *pingAggregate {
+	a := &pingAggregate{Id: core.NewAggregateId()}
+	a.AddDomainEvent(pinged{Id: a.Id})
+	return a
+}
+
+func (a *pingAggregate) ID() core.AggregateId { return a.Id }
+func (a *pingAggregate) Name() string         { return "ping" }
+func (a *pingAggregate) TableName() string    { return "pings" }
+
+// recordingTransactionProvider runs transactions against a repository that
+// discards everything and records whether the last one was committed.
+// Context transactions are run runs times, as if they were retried.
+type recordingTransactionProvider struct {
+	committed bool
+	inTx      bool
+	runs      int
+}
+
+func (p *recordingTransactionProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	if p.inTx {
+		return fn(discardRepository{})
+	}
+
+	p.committed = false
+
+	err := fn(discardRepository{})
+	if err != nil {
+		return err
+	}
+
+	p.committed = true
+
+	return nil
+}
+
+func (p *recordingTransactionProvider) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	p.inTx = true
+	defer func() { p.inTx = false }()
+
+	var err error
+	for range max(p.runs, 1) {
+		err = fn(ctx)
+	}
+
+	return err
+}
+
+type discardRepository struct{}
+
+func (discardRepository) Save(ctx context.Context, aggregate core.Aggregate) error {
+	return nil
+}
+
+func (discardRepository) Load(ctx context.Context, id core.AggregateId, aggregate core.Aggregate) error {
+	return nil
+}
+
+func (discardRepository) LoadAt(ctx context.Context, id core.AggregateId, version int, aggregate core.Aggregate) error {
+	return nil
+}
+
+func (discardRepository) LoadAsOf(ctx context.Context, id core.AggregateId, asOf time.Time, aggregate core.Aggregate) error {
+	return nil
+}
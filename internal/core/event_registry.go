@@ -0,0 +1,208 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var ErrUnknownEventType = errors.New("unknown event type")
+
+// Upcaster transforms the payload of an event from one schema version to the
+// next.
+type Upcaster func(payload map[string]any) (map[string]any, error)
+
+type registeredEvent struct {
+	version   int
+	upcasters []Upcaster
+	decode    func(payload []byte) (DomainEvent, error)
+}
+
+// EventRegistry knows the current schema version of every event type and how
+// to bring stored payloads of older versions up to date, so events can be
+// read back long after their structs have changed.
+type EventRegistry struct {
+	mu     sync.RWMutex
+	events map[string]registeredEvent
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		events: make(map[string]registeredEvent),
+	}
+}
+
+// RegisterEvent registers events of type E. Events start at schema version
+// one and every upcaster adds a version: the first one upgrades payloads
+// from version one to two, the second from two to three and so on.
+func RegisterEvent[E DomainEvent](r *EventRegistry, upcasters ...Upcaster) {
+	var zero E
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.events[zero.Type()]; ok {
+		panic(fmt.Sprintf("event %s already registered", zero.Type()))
+	}
+
+	r.events[zero.Type()] = registeredEvent{
+		version:   len(upcasters) + 1,
+		upcasters: upcasters,
+		decode: func(payload []byte) (DomainEvent, error) {
+			event := reflect.New(reflect.TypeFor[E]())
+
+			err := json.Unmarshal(payload, event.Interface())
+			if err != nil {
+				return nil, err
+			}
+
+			return event.Elem().Interface().(DomainEvent), nil
+		},
+	}
+}
+
+// SchemaVersion returns the version events of the given type are stored
+// with. Unregistered events are at version one.
+func (r *EventRegistry) SchemaVersion(eventType string) int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	event, ok := r.events[eventType]
+	if !ok {
+		return 1
+	}
+
+	return event.version
+}
+
+// Decode upcasts a stored payload to the current schema version of its event
+// type and decodes it into the event struct.
+func (r *EventRegistry) Decode(eventType string, schemaVersion int, payload []byte) (DomainEvent, error) {
+	r.mu.RLock()
+	event, ok := r.events[eventType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+
+	payload, err := r.upcast(event, eventType, schemaVersion, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := event.decode(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", eventType, err)
+	}
+
+	return decoded, nil
+}
+
+// Upcast returns the payload upgraded to the current schema version of its
+// event type.
+func (r *EventRegistry) Upcast(eventType string, schemaVersion int, payload []byte) ([]byte, error) {
+	r.mu.RLock()
+	event, ok := r.events[eventType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, eventType)
+	}
+
+	return r.upcast(event, eventType, schemaVersion, payload)
+}
+
+func (r *EventRegistry) upcast(event registeredEvent, eventType string, schemaVersion int, payload []byte) ([]byte, error) {
+	if schemaVersion < 1 || schemaVersion > event.version {
+		return nil, fmt.Errorf("unsupported schema version %d for %s", schemaVersion, eventType)
+	}
+
+	if schemaVersion == event.version {
+		return payload, nil
+	}
+
+	var fields map[string]any
+
+	err := json.Unmarshal(payload, &fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", eventType, err)
+	}
+
+	for v := schemaVersion; v < event.version; v++ {
+		fields, err = event.upcasters[v-1](fields)
+		if err != nil {
+			return nil, fmt.Errorf("failed to upcast %s from version %d: %w", eventType, v, err)
+		}
+	}
+
+	return json.Marshal(fields)
+}
+
+// RenameField returns an upcaster that moves a field to a new name.
+func RenameField(from, to string) Upcaster {
+	return func(payload map[string]any) (map[string]any, error) {
+		if value, ok := payload[from]; ok {
+			payload[to] = value
+			delete(payload, from)
+		}
+
+		return payload, nil
+	}
+}
+
+// DefaultFields returns an upcaster that sets fields missing from older
+// payloads.
+func DefaultFields(fields map[string]any) Upcaster {
+	return func(payload map[string]any) (map[string]any, error) {
+		for name, value := range fields {
+			if _, ok := payload[name]; !ok {
+				payload[name] = value
+			}
+		}
+
+		return payload, nil
+	}
+}
+
+// CamelCaseFields returns an upcaster that lowercases the first letter of
+// every field name, including those of nested objects. It upgrades payloads
+// stored with Go field names to explicitly tagged ones.
+func CamelCaseFields() Upcaster {
+	return func(payload map[string]any) (map[string]any, error) {
+		return camelCaseObject(payload), nil
+	}
+}
+
+func camelCaseObject(object map[string]any) map[string]any {
+	res := make(map[string]any, len(object))
+
+	for name, value := range object {
+		if name != "" {
+			r, size := utf8.DecodeRuneInString(name)
+			name = string(unicode.ToLower(r)) + name[size:]
+		}
+
+		res[name] = camelCaseValue(value)
+	}
+
+	return res
+}
+
+func camelCaseValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		return camelCaseObject(v)
+	case []any:
+		for i := range v {
+			v[i] = camelCaseValue(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
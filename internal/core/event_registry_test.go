@@ -0,0 +1,71 @@
+package core_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventRegistry(t *testing.T) {
+	t.Run("decodes current payloads as they are", func(t *testing.T) {
+		registry := core.NewEventRegistry()
+		core.RegisterEvent[renamed](registry, core.RenameField("Nmae", "Name"))
+
+		event, err := registry.Decode("Renamed", 2, []byte(`{"Name":"current"}`))
+
+		assert.Nil(t, err)
+		assert.Equal(t, renamed{Name: "current"}, event)
+	})
+
+	t.Run("upcasts old payloads through every version", func(t *testing.T) {
+		registry := core.NewEventRegistry()
+		core.RegisterEvent[renamed](registry,
+			core.DefaultFields(map[string]any{"Nmae": "default"}),
+			core.RenameField("Nmae", "Name"),
+		)
+
+		assert.Equal(t, 3, registry.SchemaVersion("Renamed"))
+
+		event, err := registry.Decode("Renamed", 1, []byte(`{}`))
+		assert.Nil(t, err)
+		assert.Equal(t, renamed{Name: "default"}, event)
+
+		event, err = registry.Decode("Renamed", 2, []byte(`{"Nmae":"old"}`))
+		assert.Nil(t, err)
+		assert.Equal(t, renamed{Name: "old"}, event)
+	})
+
+	t.Run("fails on unknown events and versions", func(t *testing.T) {
+		registry := core.NewEventRegistry()
+		core.RegisterEvent[renamed](registry)
+
+		_, err := registry.Decode("Unknown", 1, []byte(`{}`))
+		assert.ErrorIs(t, err, core.ErrUnknownEventType)
+
+		_, err = registry.Decode("Renamed", 2, []byte(`{}`))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("unregistered events are at version one", func(t *testing.T) {
+		assert.Equal(t, 1, core.NewEventRegistry().SchemaVersion("Unknown"))
+	})
+
+	t.Run("can't register an event twice", func(t *testing.T) {
+		registry := core.NewEventRegistry()
+		core.RegisterEvent[renamed](registry)
+
+		assert.Panics(t, func() {
+			core.RegisterEvent[renamed](registry)
+		})
+	})
+}
+
+type renamed struct {
+	Name string
+}
+
+func (e renamed) AggregateId() core.AggregateId { return core.AggregateId{} }
+func (e renamed) Type() string                  { return "Renamed" }
+func (e renamed) OccurredAt() time.Time         { return time.Time{} }
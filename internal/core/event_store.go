@@ -0,0 +1,26 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// StoredEvent is an event as recorded in the event store. The payload has
+// been upcast to the current schema version of the event.
+type StoredEvent struct {
+	AggregateId   AggregateId
+	AggregateName string
+	Type          string
+	Version       int
+	OccurredAt    time.Time
+	Metadata      Metadata
+	Payload       json.RawMessage
+}
+
+// EventStore reads the event streams of aggregates.
+type EventStore interface {
+	// ReadEvents returns at most limit events of the aggregate with a
+	// version greater than after, ordered by version.
+	ReadEvents(ctx context.Context, id AggregateId, after int, limit int) ([]StoredEvent, error)
+}
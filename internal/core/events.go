@@ -9,3 +9,10 @@ type DomainEvent interface {
 	Type() string
 	OccurredAt() time.Time
 }
+
+// RecordedEvent is an event saved with its aggregate, together with the
+// version of the aggregate it took.
+type RecordedEvent struct {
+	Event   DomainEvent
+	Version int
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// Metadata describes where a change came from. It's carried in the context
+// and stored alongside every event the change produces.
+type Metadata struct {
+	// ActorId is the user who triggered the change.
+	ActorId string `json:"actorId,omitempty"`
+	// TenantId is the tenant of the actor.
+	TenantId string `json:"tenantId,omitempty"`
+	// CorrelationId is shared by everything done on behalf of the same
+	// originating request, including work triggered by its events.
+	CorrelationId string `json:"correlationId,omitempty"`
+	// CausationId identifies the message that directly caused the change:
+	// the request for changes made by request handlers, the event for
+	// changes made by event handlers.
+	CausationId string `json:"causationId,omitempty"`
+	// RequestId is the id of the originating request.
+	RequestId string `json:"requestId,omitempty"`
+}
+
+// Merge returns the metadata with the non-empty fields of other applied on
+// top of it.
+func (m Metadata) Merge(other Metadata) Metadata {
+	if other.ActorId != "" {
+		m.ActorId = other.ActorId
+	}
+	if other.TenantId != "" {
+		m.TenantId = other.TenantId
+	}
+	if other.CorrelationId != "" {
+		m.CorrelationId = other.CorrelationId
+	}
+	if other.CausationId != "" {
+		m.CausationId = other.CausationId
+	}
+	if other.RequestId != "" {
+		m.RequestId = other.RequestId
+	}
+
+	return m
+}
+
+type metadataKey struct{}
+
+// WithMetadata merges the metadata into the one already in the context.
+func WithMetadata(ctx context.Context, metadata Metadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, MetadataFromContext(ctx).Merge(metadata))
+}
+
+func MetadataFromContext(ctx context.Context) Metadata {
+	metadata, _ := ctx.Value(metadataKey{}).(Metadata)
+	return metadata
+}
+
+// EventCausationId identifies an event as the cause of the changes made by
+// its handlers. No two events of an aggregate share a version, so the id is
+// unique and points at the event in the event store.
+func EventCausationId(event RecordedEvent) string {
+	return fmt.Sprintf("%s/%d", event.Event.AggregateId(), event.Version)
+}
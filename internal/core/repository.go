@@ -2,11 +2,36 @@ package core
 
 import (
 	"context"
+	"errors"
+	"time"
+)
+
+var (
+	ErrConcurrencyConflict = errors.New("optimistic concurrency conflict: aggregate has been modified")
+	ErrDuplicateKey        = errors.New("unique key has already been reserved")
+	ErrNotEventSourced     = errors.New("aggregate can't be rebuilt from its events")
+	ErrIsolationConflict   = errors.New("transaction can't join one with a weaker isolation level")
+	// ErrSerializationFailure is returned when a transaction couldn't be
+	// serialized with concurrent ones. Running it again may succeed.
+	ErrSerializationFailure = errors.New("transaction conflicted with a concurrent one")
 )
 
 type Repository interface {
 	Save(ctx context.Context, aggregate Aggregate) error
 	Load(ctx context.Context, id AggregateId, aggregate Aggregate) error
+	// LoadAt rebuilds the aggregate from its events up to and including the
+	// given version. The aggregate must be EventSourced.
+	LoadAt(ctx context.Context, id AggregateId, version int, aggregate Aggregate) error
+	// LoadAsOf rebuilds the aggregate from the events that had occurred by
+	// the given time. The aggregate must be EventSourced.
+	LoadAsOf(ctx context.Context, id AggregateId, asOf time.Time, aggregate Aggregate) error
+}
+
+// EventSourced is implemented by aggregates whose state can be rebuilt by
+// applying their events in order.
+type EventSourced interface {
+	Aggregate
+	ApplyEvent(event DomainEvent)
 }
 
 type TransactionSignature func(repo Repository) error
@@ -14,3 +39,76 @@ type TransactionSignature func(repo Repository) error
 type TransactionProvider interface {
 	RunTransactional(ctx context.Context, fn TransactionSignature) error
 }
+
+// IsolationLevel is the isolation level of a transaction. The default is
+// the one of the database.
+type IsolationLevel int
+
+const (
+	IsolationDefault IsolationLevel = iota
+	IsolationReadCommitted
+	IsolationRepeatableRead
+	IsolationSerializable
+)
+
+type isolationLevelKey struct{}
+
+// WithIsolationLevel makes the transactions started with the context run
+// at the given level. Transactions joining one that is already running
+// fail with ErrIsolationConflict if they ask for a stricter level.
+func WithIsolationLevel(ctx context.Context, level IsolationLevel) context.Context {
+	return context.WithValue(ctx, isolationLevelKey{}, level)
+}
+
+func IsolationLevelFromContext(ctx context.Context) IsolationLevel {
+	level, _ := ctx.Value(isolationLevelKey{}).(IsolationLevel)
+	return level
+}
+
+// UniqueKey is a value that can be claimed by a single aggregate only.
+type UniqueKey struct {
+	Scope string
+	Value string
+}
+
+// UniqueKeyHolder is implemented by aggregates that reserve unique keys when
+// they are first saved.
+type UniqueKeyHolder interface {
+	UniqueKeys() []UniqueKey
+}
+
+// ContextTransactionProvider can start a transaction that is carried in a
+// context. RunTransactional calls made with such a context join the
+// transaction, so work spanning several handlers commits atomically.
+// The transaction may be run again when it fails with
+// ErrSerializationFailure, calling fn once per run.
+type ContextTransactionProvider interface {
+	TransactionProvider
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Update loads an aggregate, lets fn change it and saves it in a single
+// transaction. A fresh aggregate is loaded on every attempt, so Update is
+// safe to use with retrying transaction providers.
+func Update[A any, T interface {
+	*A
+	Aggregate
+}](ctx context.Context, tx TransactionProvider, id AggregateId, fn func(aggregate T) error) error {
+	return tx.RunTransactional(ctx, func(repo Repository) error {
+		aggregate := T(new(A))
+
+		defer aggregate.ClearUncommittedEvents()
+
+		err := repo.Load(ctx, id, aggregate)
+		if err != nil {
+			return err
+		}
+
+		err = fn(aggregate)
+		if err != nil {
+			return err
+		}
+
+		return repo.Save(ctx, aggregate)
+	})
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 5,
+		BaseDelay:   10 * time.Millisecond,
+		MaxDelay:    500 * time.Millisecond,
+	}
+}
+
+// backoff returns a random delay between zero and an exponentially growing
+// cap, so competing transactions don't retry in lockstep.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << (attempt - 1)
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int64N(int64(ceiling)))
+}
+
+// RetryOnConflict calls fn until it succeeds, fails with an error other than
+// ErrConcurrencyConflict or the attempts of the policy run out.
+func RetryOnConflict(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	return Retry(ctx, policy, func(err error) bool {
+		return errors.Is(err, ErrConcurrencyConflict)
+	}, fn)
+}
+
+// Retry calls fn until it succeeds, fails with an error that isn't
+// retryable or the attempts of the policy run out.
+func Retry(ctx context.Context, policy RetryPolicy, retryable func(err error) bool, fn func() error) error {
+	var err error
+
+	attempts := max(policy.MaxAttempts, 1)
+
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn()
+		if err == nil || !retryable(err) {
+			return err
+		}
+
+		if attempt == attempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(policy.backoff(attempt)):
+		}
+	}
+
+	return err
+}
+
+// RetryingTransactionProvider runs the whole transaction again when it fails
+// on a concurrency conflict. The transaction function has to load the
+// aggregates it changes, so every attempt works on the latest state.
+type RetryingTransactionProvider struct {
+	next   TransactionProvider
+	policy RetryPolicy
+}
+
+func NewRetryingTransactionProvider(next TransactionProvider, policy RetryPolicy) *RetryingTransactionProvider {
+	return &RetryingTransactionProvider{
+		next:   next,
+		policy: policy,
+	}
+}
+
+func (p *RetryingTransactionProvider) RunTransactional(ctx context.Context, fn TransactionSignature) error {
+	return RetryOnConflict(ctx, p.policy, func() error {
+		return p.next.RunTransactional(ctx, fn)
+	})
+}
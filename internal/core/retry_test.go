@@ -0,0 +1,124 @@
+package core_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryingTransactionProvider(t *testing.T) {
+	policy := core.RetryPolicy{
+		MaxAttempts: 3,
+		BaseDelay:   time.Millisecond,
+		MaxDelay:    5 * time.Millisecond,
+	}
+
+	t.Run("retries until the transaction succeeds", func(t *testing.T) {
+		tx := &failingTransactionProvider{failures: 2, err: core.ErrConcurrencyConflict}
+		provider := core.NewRetryingTransactionProvider(tx, policy)
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 3, tx.calls)
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		tx := &failingTransactionProvider{failures: 10, err: fmt.Errorf("save: %w", core.ErrConcurrencyConflict)}
+		provider := core.NewRetryingTransactionProvider(tx, policy)
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return nil
+		})
+
+		assert.ErrorIs(t, err, core.ErrConcurrencyConflict)
+		assert.Equal(t, 3, tx.calls)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		tx := &failingTransactionProvider{failures: 10, err: errors.New("boom")}
+		provider := core.NewRetryingTransactionProvider(tx, policy)
+
+		err := provider.RunTransactional(context.Background(), func(repo core.Repository) error {
+			return nil
+		})
+
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, tx.calls)
+	})
+
+	t.Run("stops when context is cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		tx := &failingTransactionProvider{failures: 10, err: core.ErrConcurrencyConflict}
+		provider := core.NewRetryingTransactionProvider(tx, core.RetryPolicy{
+			MaxAttempts: 3,
+			BaseDelay:   time.Hour,
+			MaxDelay:    time.Hour,
+		})
+
+		err := provider.RunTransactional(ctx, func(repo core.Repository) error {
+			return nil
+		})
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Equal(t, 1, tx.calls)
+	})
+}
+
+func TestRetry(t *testing.T) {
+	policy := core.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	transient := errors.New("transient")
+	retryable := func(err error) bool { return errors.Is(err, transient) }
+
+	t.Run("retries the errors it's told to", func(t *testing.T) {
+		calls := 0
+
+		err := core.Retry(context.Background(), policy, retryable, func() error {
+			calls++
+			if calls < 3 {
+				return fmt.Errorf("run: %w", transient)
+			}
+			return nil
+		})
+
+		assert.Nil(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("doesn't retry other errors", func(t *testing.T) {
+		calls := 0
+
+		err := core.Retry(context.Background(), policy, retryable, func() error {
+			calls++
+			return core.ErrConcurrencyConflict
+		})
+
+		assert.ErrorIs(t, err, core.ErrConcurrencyConflict)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+type failingTransactionProvider struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (p *failingTransactionProvider) RunTransactional(ctx context.Context, fn core.TransactionSignature) error {
+	p.calls++
+
+	if p.calls <= p.failures {
+		return p.err
+	}
+
+	return fn(nil)
+}
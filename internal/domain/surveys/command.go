@@ -1,16 +1,87 @@
 package surveys
 
+import (
+	"errors"
+	"time"
+)
+
 type CreateSurveyCommand struct {
 	Title       string  `json:"title"`
 	Description *string `json:"description"`
 	TenantId    string  `json:"tenantId"`
 }
 
+func (c CreateSurveyCommand) CommandName() string {
+	return "create-survey"
+}
+
+func (c CreateSurveyCommand) Validate() error {
+	if c.Title == "" || c.TenantId == "" {
+		return errors.New("missing required fields")
+	}
+
+	return nil
+}
+
 type SetMaxParticipantsCommand struct {
 	SurveyId        string `json:"surveyId"`
 	MaxParticipants int    `json:"maxParticipants"`
 }
 
+func (c SetMaxParticipantsCommand) CommandName() string {
+	return "set-max-participants"
+}
+
+func (c SetMaxParticipantsCommand) Validate() error {
+	_, err := SurveyIdFromString(c.SurveyId)
+	return err
+}
+
+type SetAnonymityModeCommand struct {
+	SurveyId      string `json:"surveyId"`
+	AnonymityMode string `json:"anonymityMode"`
+}
+
+func (c SetAnonymityModeCommand) CommandName() string {
+	return "set-anonymity-mode"
+}
+
+func (c SetAnonymityModeCommand) Validate() error {
+	if _, err := SurveyIdFromString(c.SurveyId); err != nil {
+		return err
+	}
+
+	_, err := NewAnonymityMode(c.AnonymityMode)
+	return err
+}
+
+type SetInvitationRequiredCommand struct {
+	SurveyId           string `json:"surveyId"`
+	InvitationRequired bool   `json:"invitationRequired"`
+}
+
+func (c SetInvitationRequiredCommand) CommandName() string {
+	return "set-invitation-required"
+}
+
+func (c SetInvitationRequiredCommand) Validate() error {
+	_, err := SurveyIdFromString(c.SurveyId)
+	return err
+}
+
+type ReleaseSurveyCommand struct {
+	SurveyId string `json:"surveyId"`
+}
+
+func (c ReleaseSurveyCommand) CommandName() string {
+	return "release-survey"
+}
+
+func (c ReleaseSurveyCommand) Validate() error {
+	_, err := SurveyIdFromString(c.SurveyId)
+	return err
+}
+
 type AddQuestionCommand struct {
 	SurveyId        string   `json:"surveyId"`
 	Title           string   `json:"title"`
@@ -18,3 +89,81 @@ type AddQuestionCommand struct {
 	AllowMultiple   bool     `json:"allowMultiple"`
 	QuestionOptions []string `json:"questionOptions"`
 }
+
+func (c AddQuestionCommand) CommandName() string {
+	return "add-question"
+}
+
+func (c AddQuestionCommand) Validate() error {
+	_, err := SurveyIdFromString(c.SurveyId)
+	return err
+}
+
+type AddCollaboratorCommand struct {
+	SurveyId   string `json:"surveyId"`
+	UserId     string `json:"userId"`
+	Permission string `json:"permission"`
+}
+
+func (c AddCollaboratorCommand) CommandName() string {
+	return "add-collaborator"
+}
+
+func (c AddCollaboratorCommand) Validate() error {
+	if _, err := SurveyIdFromString(c.SurveyId); err != nil {
+		return err
+	}
+
+	_, err := NewCollaboratorPermission(c.Permission)
+	return err
+}
+
+type RemoveCollaboratorCommand struct {
+	SurveyId string `json:"surveyId"`
+	UserId   string `json:"userId"`
+}
+
+func (c RemoveCollaboratorCommand) CommandName() string {
+	return "remove-collaborator"
+}
+
+func (c RemoveCollaboratorCommand) Validate() error {
+	_, err := SurveyIdFromString(c.SurveyId)
+	return err
+}
+
+type CreateInvitationsCommand struct {
+	SurveyId  string    `json:"surveyId"`
+	Count     int       `json:"count"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (c CreateInvitationsCommand) CommandName() string {
+	return "create-invitations"
+}
+
+func (c CreateInvitationsCommand) Validate() error {
+	if _, err := SurveyIdFromString(c.SurveyId); err != nil {
+		return err
+	}
+
+	return CheckInvitationCount(c.Count)
+}
+
+type RevokeInvitationCommand struct {
+	SurveyId     string `json:"surveyId"`
+	InvitationId string `json:"invitationId"`
+}
+
+func (c RevokeInvitationCommand) CommandName() string {
+	return "revoke-invitation"
+}
+
+func (c RevokeInvitationCommand) Validate() error {
+	if _, err := SurveyIdFromString(c.SurveyId); err != nil {
+		return err
+	}
+
+	_, err := InvitationIdFromString(c.InvitationId)
+	return err
+}
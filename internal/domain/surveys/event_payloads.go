@@ -0,0 +1,412 @@
+package surveys
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Events are stored and published as JSON. Like the snapshots, their stored
+// form is the contract: the payloads below fix the field names with their
+// JSON tags, so the event structs can be refactored freely. A change to a
+// payload needs an upcaster in RegisterEvents.
+
+// decodePayload decodes the stored form of an event.
+func decodePayload[P any](data []byte) (P, error) {
+	var payload P
+	err := json.Unmarshal(data, &payload)
+	return payload, err
+}
+
+type surveyCreatedPayload struct {
+	Id            SurveyId      `json:"id"`
+	Title         string        `json:"title"`
+	Description   *string       `json:"description"`
+	TenantId      string        `json:"tenantId"`
+	OwnerId       string        `json:"ownerId"`
+	SurveyStatus  SurveyStatus  `json:"surveyStatus"`
+	AnonymityMode AnonymityMode `json:"anonymityMode"`
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+func (e SurveyCreated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(surveyCreatedPayload(e))
+}
+
+func (e *SurveyCreated) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[surveyCreatedPayload](data)
+	*e = SurveyCreated(payload)
+	return err
+}
+
+type questionAddedPayload struct {
+	Id        SurveyId  `json:"id"`
+	Question  Question  `json:"question"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e QuestionAdded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(questionAddedPayload(e))
+}
+
+func (e *QuestionAdded) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[questionAddedPayload](data)
+	*e = QuestionAdded(payload)
+	return err
+}
+
+type anonymityModeChangedPayload struct {
+	Id            SurveyId      `json:"id"`
+	AnonymityMode AnonymityMode `json:"anonymityMode"`
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+func (e AnonymityModeChanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(anonymityModeChangedPayload(e))
+}
+
+func (e *AnonymityModeChanged) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[anonymityModeChangedPayload](data)
+	*e = AnonymityModeChanged(payload)
+	return err
+}
+
+type invitationRequirementChangedPayload struct {
+	Id                 SurveyId  `json:"id"`
+	InvitationRequired bool      `json:"invitationRequired"`
+	CreatedAt          time.Time `json:"createdAt"`
+}
+
+func (e InvitationRequirementChanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(invitationRequirementChangedPayload(e))
+}
+
+func (e *InvitationRequirementChanged) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[invitationRequirementChangedPayload](data)
+	*e = InvitationRequirementChanged(payload)
+	return err
+}
+
+type maxParticipantsChangedPayload struct {
+	Id              SurveyId  `json:"id"`
+	MaxParticipants int       `json:"maxParticipants"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func (e MaxParticipantsChanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(maxParticipantsChangedPayload(e))
+}
+
+func (e *MaxParticipantsChanged) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[maxParticipantsChangedPayload](data)
+	*e = MaxParticipantsChanged(payload)
+	return err
+}
+
+type surveyEndTimeChangedPayload struct {
+	Id        SurveyId  `json:"id"`
+	EndTime   time.Time `json:"endTime"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e SurveyEndTimeChanged) MarshalJSON() ([]byte, error) {
+	return json.Marshal(surveyEndTimeChangedPayload(e))
+}
+
+func (e *SurveyEndTimeChanged) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[surveyEndTimeChangedPayload](data)
+	*e = SurveyEndTimeChanged(payload)
+	return err
+}
+
+type surveyReleasedPayload struct {
+	Id        SurveyId  `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e SurveyReleased) MarshalJSON() ([]byte, error) {
+	return json.Marshal(surveyReleasedPayload(e))
+}
+
+func (e *SurveyReleased) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[surveyReleasedPayload](data)
+	*e = SurveyReleased(payload)
+	return err
+}
+
+type submissionReceivedPayload struct {
+	Id         SurveyId         `json:"id"`
+	ResponseId SurveyResponseId `json:"responseId"`
+	ReceivedAt time.Time        `json:"receivedAt"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e SubmissionReceived) MarshalJSON() ([]byte, error) {
+	return json.Marshal(submissionReceivedPayload(e))
+}
+
+func (e *SubmissionReceived) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[submissionReceivedPayload](data)
+	*e = SubmissionReceived(payload)
+	return err
+}
+
+type slotReservedPayload struct {
+	Id         SurveyId         `json:"id"`
+	ResponseId SurveyResponseId `json:"responseId"`
+	ExpiresAt  time.Time        `json:"expiresAt"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e SlotReserved) MarshalJSON() ([]byte, error) {
+	return json.Marshal(slotReservedPayload(e))
+}
+
+func (e *SlotReserved) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[slotReservedPayload](data)
+	*e = SlotReserved(payload)
+	return err
+}
+
+type slotReleasedPayload struct {
+	Id         SurveyId         `json:"id"`
+	ResponseId SurveyResponseId `json:"responseId"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e SlotReleased) MarshalJSON() ([]byte, error) {
+	return json.Marshal(slotReleasedPayload(e))
+}
+
+func (e *SlotReleased) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[slotReleasedPayload](data)
+	*e = SlotReleased(payload)
+	return err
+}
+
+type surveyCompletedPayload struct {
+	Id        SurveyId  `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e SurveyCompleted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(surveyCompletedPayload(e))
+}
+
+func (e *SurveyCompleted) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[surveyCompletedPayload](data)
+	*e = SurveyCompleted(payload)
+	return err
+}
+
+type surveyLockedPayload struct {
+	Id        SurveyId  `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e SurveyLocked) MarshalJSON() ([]byte, error) {
+	return json.Marshal(surveyLockedPayload(e))
+}
+
+func (e *SurveyLocked) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[surveyLockedPayload](data)
+	*e = SurveyLocked(payload)
+	return err
+}
+
+type collaboratorAddedPayload struct {
+	Id         SurveyId               `json:"id"`
+	UserId     string                 `json:"userId"`
+	Permission CollaboratorPermission `json:"permission"`
+	CreatedAt  time.Time              `json:"createdAt"`
+}
+
+func (e CollaboratorAdded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(collaboratorAddedPayload(e))
+}
+
+func (e *CollaboratorAdded) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[collaboratorAddedPayload](data)
+	*e = CollaboratorAdded(payload)
+	return err
+}
+
+type collaboratorRemovedPayload struct {
+	Id        SurveyId  `json:"id"`
+	UserId    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+func (e CollaboratorRemoved) MarshalJSON() ([]byte, error) {
+	return json.Marshal(collaboratorRemovedPayload(e))
+}
+
+func (e *CollaboratorRemoved) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[collaboratorRemovedPayload](data)
+	*e = CollaboratorRemoved(payload)
+	return err
+}
+
+type surveyResponseCreatedPayload struct {
+	Id                SurveyResponseId `json:"id"`
+	SurveyId          SurveyId         `json:"surveyId"`
+	RespondentId      RespondentId     `json:"respondentId"`
+	NumberOfQuestions int              `json:"numberOfQuestions"`
+	CreatedAt         time.Time        `json:"createdAt"`
+}
+
+func (e SurveyResponseCreated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(surveyResponseCreatedPayload(e))
+}
+
+func (e *SurveyResponseCreated) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[surveyResponseCreatedPayload](data)
+	*e = SurveyResponseCreated(payload)
+	return err
+}
+
+type questionAnsweredPayload struct {
+	Id         SurveyResponseId   `json:"id"`
+	QuestionId QuestionId         `json:"questionId"`
+	Choices    []QuestionOptionId `json:"choices"`
+	CreatedAt  time.Time          `json:"createdAt"`
+}
+
+func (e QuestionAnswered) MarshalJSON() ([]byte, error) {
+	return json.Marshal(questionAnsweredPayload(e))
+}
+
+func (e *QuestionAnswered) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[questionAnsweredPayload](data)
+	*e = QuestionAnswered(payload)
+	return err
+}
+
+type responseSubmittedPayload struct {
+	Id        SurveyResponseId `json:"id"`
+	SurveyId  SurveyId         `json:"surveyId"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func (e ResponseSubmitted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(responseSubmittedPayload(e))
+}
+
+func (e *ResponseSubmitted) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[responseSubmittedPayload](data)
+	*e = ResponseSubmitted(payload)
+	return err
+}
+
+type responseRejectedPayload struct {
+	Id        SurveyResponseId `json:"id"`
+	SurveyId  SurveyId         `json:"surveyId"`
+	Reason    string           `json:"reason"`
+	CreatedAt time.Time        `json:"createdAt"`
+}
+
+func (e ResponseRejected) MarshalJSON() ([]byte, error) {
+	return json.Marshal(responseRejectedPayload(e))
+}
+
+func (e *ResponseRejected) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[responseRejectedPayload](data)
+	*e = ResponseRejected(payload)
+	return err
+}
+
+type invitationCreatedPayload struct {
+	Id        InvitationId `json:"id"`
+	SurveyId  SurveyId     `json:"surveyId"`
+	TokenHash string       `json:"tokenHash"`
+	ExpiresAt time.Time    `json:"expiresAt"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+func (e InvitationCreated) MarshalJSON() ([]byte, error) {
+	return json.Marshal(invitationCreatedPayload(e))
+}
+
+func (e *InvitationCreated) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[invitationCreatedPayload](data)
+	*e = InvitationCreated(payload)
+	return err
+}
+
+type invitationRedeemedPayload struct {
+	Id         InvitationId     `json:"id"`
+	ResponseId SurveyResponseId `json:"responseId"`
+	RedeemedAt time.Time        `json:"redeemedAt"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e InvitationRedeemed) MarshalJSON() ([]byte, error) {
+	return json.Marshal(invitationRedeemedPayload(e))
+}
+
+func (e *InvitationRedeemed) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[invitationRedeemedPayload](data)
+	*e = InvitationRedeemed(payload)
+	return err
+}
+
+type invitationRevokedPayload struct {
+	Id        InvitationId `json:"id"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+func (e InvitationRevoked) MarshalJSON() ([]byte, error) {
+	return json.Marshal(invitationRevokedPayload(e))
+}
+
+func (e *InvitationRevoked) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[invitationRevokedPayload](data)
+	*e = InvitationRevoked(payload)
+	return err
+}
+
+type submissionSagaStartedPayload struct {
+	ResponseId SurveyResponseId `json:"responseId"`
+	SurveyId   SurveyId         `json:"surveyId"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e SubmissionSagaStarted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(submissionSagaStartedPayload(e))
+}
+
+func (e *SubmissionSagaStarted) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[submissionSagaStartedPayload](data)
+	*e = SubmissionSagaStarted(payload)
+	return err
+}
+
+type submissionSagaAcceptedPayload struct {
+	ResponseId SurveyResponseId `json:"responseId"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e SubmissionSagaAccepted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(submissionSagaAcceptedPayload(e))
+}
+
+func (e *SubmissionSagaAccepted) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[submissionSagaAcceptedPayload](data)
+	*e = SubmissionSagaAccepted(payload)
+	return err
+}
+
+type submissionSagaRejectedPayload struct {
+	ResponseId SurveyResponseId `json:"responseId"`
+	Reason     string           `json:"reason"`
+	CreatedAt  time.Time        `json:"createdAt"`
+}
+
+func (e SubmissionSagaRejected) MarshalJSON() ([]byte, error) {
+	return json.Marshal(submissionSagaRejectedPayload(e))
+}
+
+func (e *SubmissionSagaRejected) UnmarshalJSON(data []byte) error {
+	payload, err := decodePayload[submissionSagaRejectedPayload](data)
+	*e = SubmissionSagaRejected(payload)
+	return err
+}
@@ -0,0 +1,62 @@
+package surveys
+
+import (
+	"github.com/google/uuid"
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+// RegisterEvents registers every event of the package with its schema
+// history. When the payload of an event changes, add an upcaster bringing
+// the previous version up to date instead of editing the old ones.
+func RegisterEvents(r *core.EventRegistry) {
+	// Payloads were stored with Go field names until the events got explicit
+	// camelCase JSON tags, which added a version to every event.
+	tagged := core.CamelCaseFields()
+
+	core.RegisterEvent[SurveyCreated](r,
+		// v2: surveys record their owner and anonymity mode.
+		core.DefaultFields(map[string]any{
+			"OwnerId":       "",
+			"AnonymityMode": string(Anonymous),
+		}),
+		tagged,
+	)
+	core.RegisterEvent[QuestionAdded](r, tagged)
+	core.RegisterEvent[AnonymityModeChanged](r, tagged)
+	core.RegisterEvent[InvitationRequirementChanged](r)
+	core.RegisterEvent[MaxParticipantsChanged](r, tagged)
+	core.RegisterEvent[SurveyEndTimeChanged](r, tagged)
+	core.RegisterEvent[SurveyReleased](r, tagged)
+	core.RegisterEvent[SubmissionReceived](r,
+		// v2: submissions record the response they were made with.
+		core.DefaultFields(map[string]any{
+			"ResponseId": uuid.Nil.String(),
+		}),
+		tagged,
+	)
+	core.RegisterEvent[SlotReserved](r, tagged)
+	core.RegisterEvent[SlotReleased](r, tagged)
+	core.RegisterEvent[SurveyCompleted](r, tagged)
+	core.RegisterEvent[SurveyLocked](r, tagged)
+	core.RegisterEvent[CollaboratorAdded](r, tagged)
+	core.RegisterEvent[CollaboratorRemoved](r, tagged)
+
+	core.RegisterEvent[SurveyResponseCreated](r,
+		// v2: responses record their respondent.
+		core.DefaultFields(map[string]any{
+			"RespondentId": "",
+		}),
+		tagged,
+	)
+	core.RegisterEvent[QuestionAnswered](r, tagged)
+	core.RegisterEvent[ResponseSubmitted](r, tagged)
+	core.RegisterEvent[ResponseRejected](r, tagged)
+
+	core.RegisterEvent[InvitationCreated](r, tagged)
+	core.RegisterEvent[InvitationRedeemed](r, tagged)
+	core.RegisterEvent[InvitationRevoked](r, tagged)
+
+	core.RegisterEvent[SubmissionSagaStarted](r, tagged)
+	core.RegisterEvent[SubmissionSagaAccepted](r, tagged)
+	core.RegisterEvent[SubmissionSagaRejected](r, tagged)
+}
@@ -0,0 +1,99 @@
+package surveys_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayV1Events(t *testing.T) {
+	registry := core.NewEventRegistry()
+	surveys.RegisterEvents(registry)
+
+	t.Run("replays a v1 survey stream", func(t *testing.T) {
+		survey := new(surveys.Survey)
+
+		for _, event := range loadFixture(t, registry, "testdata/v1/survey.json") {
+			survey.ApplyEvent(event)
+		}
+
+		assert.Equal(t, "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f", survey.Id.String())
+		assert.Equal(t, "Team satisfaction", survey.Title)
+		assert.Equal(t, "tenant", survey.TenantId)
+		assert.Equal(t, "", survey.OwnerId)
+		assert.Equal(t, surveys.Anonymous, survey.AnonymityMode)
+		assert.Equal(t, surveys.Released, survey.Status())
+		assert.Equal(t, 10, survey.MaxParticipants)
+		assert.Len(t, survey.Questions, 1)
+		assert.Len(t, survey.Questions[0].QuestionOptions, 2)
+		assert.Equal(t, 1, survey.AnswersReceived())
+	})
+
+	t.Run("replays a v1 response stream", func(t *testing.T) {
+		response := new(surveys.SurveyResponse)
+
+		for _, event := range loadFixture(t, registry, "testdata/v1/response.json") {
+			response.ApplyEvent(event)
+		}
+
+		assert.Equal(t, "3d4e5f6a-7b8c-4d9e-8f0a-2b3c4d5e6f7a", response.Id.String())
+		assert.Equal(t, "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f", response.SurveyId.String())
+		assert.Equal(t, surveys.RespondentId(""), response.RespondentId)
+		assert.Len(t, response.Responses, 1)
+		assert.Equal(t, surveys.ResponseStatusSubmitted, response.Status)
+	})
+}
+
+func TestRegisteredEvents(t *testing.T) {
+	registry := core.NewEventRegistry()
+	surveys.RegisterEvents(registry)
+
+	t.Run("current events round trip through the registry", func(t *testing.T) {
+		survey, err := surveys.NewSurvey("title", nil, "tenant", "owner")
+		assert.Nil(t, err)
+
+		for _, event := range survey.GetUncommittedEvents() {
+			payload, err := json.Marshal(event)
+			assert.Nil(t, err)
+
+			decoded, err := registry.Decode(event.Type(), registry.SchemaVersion(event.Type()), payload)
+			assert.Nil(t, err)
+			assert.IsType(t, event, decoded)
+
+			roundTripped, err := json.Marshal(decoded)
+			assert.Nil(t, err)
+			assert.JSONEq(t, string(payload), string(roundTripped))
+		}
+	})
+}
+
+type storedEvent struct {
+	Type          string          `json:"type"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+func loadFixture(t *testing.T, registry *core.EventRegistry, path string) []core.DomainEvent {
+	data, err := os.ReadFile(path)
+	assert.Nil(t, err)
+
+	var stored []storedEvent
+
+	err = json.Unmarshal(data, &stored)
+	assert.Nil(t, err)
+
+	events := make([]core.DomainEvent, 0, len(stored))
+
+	for _, s := range stored {
+		event, err := registry.Decode(s.Type, s.SchemaVersion, s.Payload)
+		assert.Nil(t, err)
+
+		events = append(events, event)
+	}
+
+	return events
+}
@@ -0,0 +1,266 @@
+package surveys
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+var ErrInvalidInvitationToken = errors.New("invalid invitation token")
+
+// maxInvitationsPerBatch is the most invitations that can be created at
+// once.
+const maxInvitationsPerBatch = 1000
+
+// CheckInvitationCount checks the number of invitations to create at once.
+func CheckInvitationCount(count int) error {
+	if count < 1 || count > maxInvitationsPerBatch {
+		return fmt.Errorf("invitation count must be between 1 and %d", maxInvitationsPerBatch)
+	}
+
+	return nil
+}
+
+type InvitationId core.AggregateId
+
+func NewInvitationId() InvitationId {
+	return InvitationId(core.NewAggregateId())
+}
+
+func (s InvitationId) String() string {
+	return core.AggregateId(s).String()
+}
+
+func (id InvitationId) MarshalJSON() ([]byte, error) {
+	return core.AggregateId(id).MarshalJSON()
+}
+
+func (id *InvitationId) UnmarshalJSON(data []byte) error {
+	return (*core.AggregateId)(id).UnmarshalJSON(data)
+}
+
+func (id InvitationId) Value() (driver.Value, error) {
+	return core.AggregateId(id).Value()
+}
+
+func (id *InvitationId) Scan(value any) error {
+	return (*core.AggregateId)(id).Scan(value)
+}
+
+func InvitationIdFromString(s string) (InvitationId, error) {
+	id, err := uuid.Parse(s)
+	if err != nil {
+		return InvitationId{}, err
+	}
+
+	return InvitationId(core.AggregateId(id)), nil
+}
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "pending"
+	InvitationStatusRedeemed InvitationStatus = "redeemed"
+	InvitationStatusRevoked  InvitationStatus = "revoked"
+	InvitationStatusExpired  InvitationStatus = "expired"
+)
+
+// Invitation allows a single respondent to answer a survey. Only a hash of
+// the secret part of the token is stored, the token itself is handed out
+// once when the invitation is created.
+type Invitation struct {
+	Id               InvitationId
+	SurveyId         SurveyId
+	TokenHash        string
+	ExpiresAt        time.Time
+	InvitationStatus InvitationStatus
+	RedeemedAt       time.Time
+	ResponseId       SurveyResponseId
+
+	core.BaseAggregate
+}
+
+// IssuedInvitation is an invitation together with its token, which is only
+// available at the time the invitation is created.
+type IssuedInvitation struct {
+	Id        InvitationId
+	Token     string
+	ExpiresAt time.Time
+}
+
+// NewInvitation creates an invitation and returns it with its token. The
+// token has the form <invitation id>.<secret> so it can be resolved without
+// a lookup by token.
+func NewInvitation(surveyId SurveyId, expiresAt time.Time, now time.Time) (*Invitation, string, error) {
+	if !expiresAt.After(now) {
+		return nil, "", errors.New("invitation must expire in the future")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(secret)
+
+	invitation := new(Invitation)
+
+	invitation.addEvent(InvitationCreated{
+		Id:        NewInvitationId(),
+		SurveyId:  surveyId,
+		TokenHash: hashInvitationSecret(encoded),
+		ExpiresAt: expiresAt,
+		CreatedAt: now,
+	})
+
+	return invitation, invitation.Id.String() + "." + encoded, nil
+}
+
+// ParseInvitationToken returns the invitation the token was issued for.
+func ParseInvitationToken(token string) (InvitationId, error) {
+	id, _, ok := strings.Cut(token, ".")
+	if !ok {
+		return InvitationId{}, ErrInvalidInvitationToken
+	}
+
+	invitationId, err := InvitationIdFromString(id)
+	if err != nil {
+		return InvitationId{}, ErrInvalidInvitationToken
+	}
+
+	return invitationId, nil
+}
+
+func (i Invitation) ID() core.AggregateId {
+	return core.AggregateId(i.Id)
+}
+
+func (i Invitation) Name() string {
+	return "invitation"
+}
+
+func (i Invitation) TableName() string {
+	return "invitations"
+}
+
+// Status returns the status of the invitation, taking expiry into account.
+func (i Invitation) Status(now time.Time) InvitationStatus {
+	if i.InvitationStatus == InvitationStatusPending && !now.Before(i.ExpiresAt) {
+		return InvitationStatusExpired
+	}
+
+	return i.InvitationStatus
+}
+
+// Verify checks that the token was issued for this invitation.
+func (i Invitation) Verify(token string) error {
+	id, secret, ok := strings.Cut(token, ".")
+	if !ok || id != i.Id.String() {
+		return ErrInvalidInvitationToken
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashInvitationSecret(secret)), []byte(i.TokenHash)) != 1 {
+		return ErrInvalidInvitationToken
+	}
+
+	return nil
+}
+
+// Redeem marks the invitation as used for the response.
+func (i *Invitation) Redeem(token string, responseId SurveyResponseId, now time.Time) error {
+	return i.redeem(token, responseId, now, now)
+}
+
+// RedeemAnonymously marks the invitation as used without recording the
+// response or when it was used, so invitations to anonymous surveys can't
+// be tied to their responses.
+func (i *Invitation) RedeemAnonymously(token string, now time.Time) error {
+	return i.redeem(token, SurveyResponseId{}, time.Time{}, now)
+}
+
+func (i *Invitation) redeem(token string, responseId SurveyResponseId, redeemedAt time.Time, now time.Time) error {
+	err := i.Verify(token)
+	if err != nil {
+		return err
+	}
+
+	switch i.Status(now) {
+	case InvitationStatusRedeemed:
+		return errors.New("invitation has already been used")
+	case InvitationStatusRevoked:
+		return errors.New("invitation has been revoked")
+	case InvitationStatusExpired:
+		return errors.New("invitation has expired")
+	}
+
+	i.addEvent(InvitationRedeemed{
+		Id:         i.Id,
+		ResponseId: responseId,
+		RedeemedAt: redeemedAt,
+		CreatedAt:  time.Now(),
+	})
+
+	return nil
+}
+
+// Linked tells whether the invitation records the response it was used
+// for.
+func (i Invitation) Linked() bool {
+	return i.ResponseId != SurveyResponseId{}
+}
+
+func (i *Invitation) Revoke() error {
+	switch i.InvitationStatus {
+	case InvitationStatusRedeemed:
+		return errors.New("can't revoke a used invitation")
+	case InvitationStatusRevoked:
+		return errors.New("invitation has already been revoked")
+	}
+
+	i.addEvent(InvitationRevoked{
+		Id:        i.Id,
+		CreatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (i *Invitation) ApplyEvent(event core.DomainEvent) {
+	switch e := event.(type) {
+	case InvitationCreated:
+		i.Id = e.Id
+		i.SurveyId = e.SurveyId
+		i.TokenHash = e.TokenHash
+		i.ExpiresAt = e.ExpiresAt
+		i.InvitationStatus = InvitationStatusPending
+		i.SetCreatedAt(e.CreatedAt)
+	case InvitationRedeemed:
+		i.InvitationStatus = InvitationStatusRedeemed
+		i.RedeemedAt = e.RedeemedAt
+		i.ResponseId = e.ResponseId
+	case InvitationRevoked:
+		i.InvitationStatus = InvitationStatusRevoked
+	default:
+		panic(fmt.Sprintf("unknown event: %+v", e))
+	}
+}
+
+func (i *Invitation) addEvent(event core.DomainEvent) {
+	i.AddDomainEvent(event)
+	i.ApplyEvent(event)
+}
+
+func hashInvitationSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
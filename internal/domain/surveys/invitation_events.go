@@ -0,0 +1,63 @@
+package surveys
+
+import (
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+type InvitationCreated struct {
+	Id        InvitationId
+	SurveyId  SurveyId
+	TokenHash string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+func (e InvitationCreated) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e InvitationCreated) Type() string {
+	return "invitation-created"
+}
+
+func (e InvitationCreated) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type InvitationRedeemed struct {
+	Id         InvitationId
+	ResponseId SurveyResponseId
+	RedeemedAt time.Time
+	CreatedAt  time.Time
+}
+
+func (e InvitationRedeemed) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e InvitationRedeemed) Type() string {
+	return "invitation-redeemed"
+}
+
+func (e InvitationRedeemed) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type InvitationRevoked struct {
+	Id        InvitationId
+	CreatedAt time.Time
+}
+
+func (e InvitationRevoked) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e InvitationRevoked) Type() string {
+	return "invitation-revoked"
+}
+
+func (e InvitationRevoked) OccurredAt() time.Time {
+	return e.CreatedAt
+}
@@ -0,0 +1,115 @@
+package surveys_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInvitation(t *testing.T) {
+	t.Run("new invitation is pending and only stores a token hash", func(t *testing.T) {
+		survey := newSurvey()
+
+		invitation, token, err := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.InvitationStatusPending, invitation.Status(now()))
+		assert.True(t, strings.HasPrefix(token, invitation.Id.String()+"."))
+		assert.NotContains(t, token, invitation.TokenHash)
+
+		id, err := surveys.ParseInvitationToken(token)
+		assert.Nil(t, err)
+		assert.Equal(t, invitation.Id, id)
+	})
+
+	t.Run("tokens are unique", func(t *testing.T) {
+		survey := newSurvey()
+
+		_, first, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+		_, second, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("can't create an invitation that has already expired", func(t *testing.T) {
+		survey := newSurvey()
+
+		_, _, err := surveys.NewInvitation(survey.Id, now().Add(-time.Minute), now())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("invitation expires", func(t *testing.T) {
+		survey := newSurvey()
+
+		invitation, _, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+		assert.Equal(t, surveys.InvitationStatusExpired, invitation.Status(now().Add(2*time.Hour)))
+	})
+}
+
+func TestRedeemInvitation(t *testing.T) {
+	t.Run("can redeem an invitation once", func(t *testing.T) {
+		survey := newSurvey()
+		invitation, token, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+		responseId := surveys.NewSurveyResponseId()
+
+		err := invitation.Redeem(token, responseId, now())
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.InvitationStatusRedeemed, invitation.Status(now()))
+		assert.Equal(t, responseId, invitation.ResponseId)
+
+		err = invitation.Redeem(token, surveys.NewSurveyResponseId(), now())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("anonymous redemption doesn't record the response", func(t *testing.T) {
+		survey := newSurvey()
+		invitation, token, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+
+		err := invitation.RedeemAnonymously(token, now())
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.InvitationStatusRedeemed, invitation.Status(now()))
+		assert.False(t, invitation.Linked())
+		assert.True(t, invitation.RedeemedAt.IsZero())
+
+		err = invitation.RedeemAnonymously(token, now())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("can't redeem with a wrong token", func(t *testing.T) {
+		survey := newSurvey()
+		invitation, _, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+
+		err := invitation.Redeem(invitation.Id.String()+".wrong", surveys.NewSurveyResponseId(), now())
+		assert.ErrorIs(t, err, surveys.ErrInvalidInvitationToken)
+	})
+
+	t.Run("can't redeem an expired invitation", func(t *testing.T) {
+		survey := newSurvey()
+		invitation, token, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+
+		err := invitation.Redeem(token, surveys.NewSurveyResponseId(), now().Add(2*time.Hour))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("can't redeem a revoked invitation", func(t *testing.T) {
+		survey := newSurvey()
+		invitation, token, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+
+		err := invitation.Revoke()
+		assert.Nil(t, err)
+
+		err = invitation.Redeem(token, surveys.NewSurveyResponseId(), now())
+		assert.NotNil(t, err)
+	})
+
+	t.Run("can't revoke a redeemed invitation", func(t *testing.T) {
+		survey := newSurvey()
+		invitation, token, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+
+		_ = invitation.Redeem(token, surveys.NewSurveyResponseId(), now())
+
+		err := invitation.Revoke()
+		assert.NotNil(t, err)
+	})
+}
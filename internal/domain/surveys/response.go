@@ -10,8 +10,14 @@ import (
 	"github.com/markusryoti/survey-ddd/internal/core"
 )
 
+var ErrAlreadyResponded = errors.New("respondent has already responded to the survey")
+
 type SurveyResponseId core.AggregateId
 
+func NewSurveyResponseId() SurveyResponseId {
+	return SurveyResponseId(core.NewAggregateId())
+}
+
 func (s SurveyResponseId) String() string {
 	return core.AggregateId(s).String()
 }
@@ -32,18 +38,19 @@ func (id *SurveyResponseId) Scan(value any) error {
 	return (*core.AggregateId)(id).Scan(value)
 }
 
-func SurveyResponseIdFromString(s string) (SurveyId, error) {
+func SurveyResponseIdFromString(s string) (SurveyResponseId, error) {
 	id, err := uuid.Parse(s)
 	if err != nil {
-		return SurveyId{}, err
+		return SurveyResponseId{}, err
 	}
 
-	return SurveyId(core.AggregateId(id)), nil
+	return SurveyResponseId(core.AggregateId(id)), nil
 }
 
 type SurveyResponse struct {
 	Id                SurveyResponseId
 	SurveyId          SurveyId
+	RespondentId      RespondentId
 	NumberOfQuestions int
 	Responses         []QuestionResponse
 	TimeCreated       time.Time
@@ -64,11 +71,27 @@ func (s SurveyResponse) TableName() string {
 	return "survey_responses"
 }
 
+// UniqueKeys reserves the respondent for the survey so a second response by
+// the same respondent is rejected by the repository.
+func (s SurveyResponse) UniqueKeys() []core.UniqueKey {
+	if s.RespondentId == "" {
+		return nil
+	}
+
+	return []core.UniqueKey{
+		{
+			Scope: "survey-respondent",
+			Value: fmt.Sprintf("%s:%s", s.SurveyId, s.RespondentId),
+		},
+	}
+}
+
 type ResponseStatus string
 
 const (
 	ResponseStatusDraft     ResponseStatus = "draft"
 	ResponseStatusSubmitted ResponseStatus = "submitted"
+	ResponseStatusRejected  ResponseStatus = "rejected"
 )
 
 type QuestionResponse struct {
@@ -76,14 +99,16 @@ type QuestionResponse struct {
 	Choices    []QuestionOptionId
 }
 
-func NewSurveyResponse(id SurveyId) *SurveyResponse {
+func NewSurveyResponse(id SurveyId, respondentId RespondentId) *SurveyResponse {
 	now := time.Now()
 
 	response := &SurveyResponse{}
 
 	response.addEvent(SurveyResponseCreated{
-		SurveyId:  id,
-		CreatedAt: now,
+		Id:           NewSurveyResponseId(),
+		SurveyId:     id,
+		RespondentId: respondentId,
+		CreatedAt:    now,
 	})
 
 	return response
@@ -104,18 +129,45 @@ func (s *SurveyResponse) AddResponseToQuestion(question QuestionId, options []Qu
 	return nil
 }
 
-func (s *SurveyResponse) Submit() {
+func (s *SurveyResponse) Submit() error {
+	if s.Status != ResponseStatusDraft {
+		return errors.New("response has already been submitted")
+	}
+
 	s.addEvent(ResponseSubmitted{
+		Id:        s.Id,
 		SurveyId:  s.SurveyId,
 		CreatedAt: time.Now(),
 	})
+
+	return nil
+}
+
+// Reject marks a submitted response as not counted in the survey, for
+// example because the survey was full by the time it was processed.
+func (s *SurveyResponse) Reject(reason string) error {
+	if s.Status != ResponseStatusSubmitted {
+		return errors.New("only submitted responses can be rejected")
+	}
+
+	s.addEvent(ResponseRejected{
+		Id:        s.Id,
+		SurveyId:  s.SurveyId,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+
+	return nil
 }
 
 func (s *SurveyResponse) ApplyEvent(event core.DomainEvent) {
 	switch e := event.(type) {
 	case SurveyResponseCreated:
+		s.Id = e.Id
 		s.SurveyId = e.SurveyId
+		s.RespondentId = e.RespondentId
 		s.NumberOfQuestions = e.NumberOfQuestions
+		s.Status = ResponseStatusDraft
 		s.SetCreatedAt(e.CreatedAt)
 	case QuestionAnswered:
 		s.Responses = append(s.Responses, QuestionResponse{
@@ -124,6 +176,8 @@ func (s *SurveyResponse) ApplyEvent(event core.DomainEvent) {
 		})
 	case ResponseSubmitted:
 		s.Status = ResponseStatusSubmitted
+	case ResponseRejected:
+		s.Status = ResponseStatusRejected
 	default:
 		panic(fmt.Sprintf("unknown event: %+v", e))
 	}
@@ -9,6 +9,7 @@ import (
 type SurveyResponseCreated struct {
 	Id                SurveyResponseId
 	SurveyId          SurveyId
+	RespondentId      RespondentId
 	NumberOfQuestions int
 	CreatedAt         time.Time
 }
@@ -61,3 +62,22 @@ func (e ResponseSubmitted) Type() string {
 func (e ResponseSubmitted) OccurredAt() time.Time {
 	return e.CreatedAt
 }
+
+type ResponseRejected struct {
+	Id        SurveyResponseId
+	SurveyId  SurveyId
+	Reason    string
+	CreatedAt time.Time
+}
+
+func (e ResponseRejected) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e ResponseRejected) Type() string {
+	return "response-rejected"
+}
+
+func (e ResponseRejected) OccurredAt() time.Time {
+	return e.CreatedAt
+}
@@ -14,7 +14,7 @@ func TestNewSurveyResponse(t *testing.T) {
 			"option 1", "option 2",
 		}, false)
 
-		response := surveys.NewSurveyResponse(survey.Id)
+		response := surveys.NewSurveyResponse(survey.Id, "")
 		err := response.AddResponseToQuestion(question.Id, []surveys.QuestionOptionId{
 			question.QuestionOptions[0].Id})
 		assert.Nil(t, err)
@@ -31,7 +31,7 @@ func TestNewSurveyResponse(t *testing.T) {
 			"option1", "option2",
 		}, true)
 
-		response := surveys.NewSurveyResponse(survey.Id)
+		response := surveys.NewSurveyResponse(survey.Id, "")
 		err := response.AddResponseToQuestion(question.Id, []surveys.QuestionOptionId{
 			question.QuestionOptions[0].Id,
 		})
@@ -43,3 +43,52 @@ func TestNewSurveyResponse(t *testing.T) {
 		assert.NotNil(t, err)
 	})
 }
+
+func TestResponseUniqueKeys(t *testing.T) {
+	t.Run("anonymous response reserves nothing", func(t *testing.T) {
+		survey := newSurvey()
+		response := surveys.NewSurveyResponse(survey.Id, "")
+		assert.Len(t, response.UniqueKeys(), 0)
+	})
+
+	t.Run("identified response reserves the respondent for the survey", func(t *testing.T) {
+		survey := newSurvey()
+		response := surveys.NewSurveyResponse(survey.Id, "user:someone")
+
+		keys := response.UniqueKeys()
+		assert.Len(t, keys, 1)
+		assert.Contains(t, keys[0].Value, survey.Id.String())
+		assert.Contains(t, keys[0].Value, "user:someone")
+	})
+
+	t.Run("response gets an id and respondent", func(t *testing.T) {
+		survey := newSurvey()
+		response := surveys.NewSurveyResponse(survey.Id, "user:someone")
+
+		assert.NotEqual(t, surveys.SurveyResponseId{}, response.Id)
+		assert.Equal(t, surveys.RespondentId("user:someone"), response.RespondentId)
+	})
+}
+
+func TestRejectResponse(t *testing.T) {
+	t.Run("can't reject a draft response", func(t *testing.T) {
+		response := surveys.NewSurveyResponse(newSurvey().Id, "")
+
+		err := response.Reject("survey is full")
+		assert.NotNil(t, err)
+	})
+
+	t.Run("rejected response can't be submitted again", func(t *testing.T) {
+		response := surveys.NewSurveyResponse(newSurvey().Id, "")
+
+		err := response.Submit()
+		assert.Nil(t, err)
+
+		err = response.Reject("survey is full")
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.ResponseStatusRejected, response.Status)
+
+		err = response.Submit()
+		assert.NotNil(t, err)
+	})
+}
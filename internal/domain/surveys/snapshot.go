@@ -0,0 +1,349 @@
+package surveys
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Aggregates are stored as JSON snapshots. The snapshots below are the
+// stored contract: their field names are fixed by the JSON tags and don't
+// follow the domain structs, so those can be refactored freely. A change to
+// a snapshot has to keep reading what has already been stored, and a change
+// that can't must bump snapshotSchemaVersion.
+//
+// Snapshots written before the schema version was introduced have Go field
+// names, which decode into the same fields as encoding/json matches names
+// case-insensitively. SQL doesn't, so the fields queried in SQL were copied
+// to their current names in those rows by a migration.
+const snapshotSchemaVersion = 1
+
+func checkSnapshotVersion(name string, version int) error {
+	if version > snapshotSchemaVersion {
+		return fmt.Errorf("unsupported %s snapshot version %d", name, version)
+	}
+
+	return nil
+}
+
+type surveySnapshot struct {
+	SchemaVersion      int                   `json:"schemaVersion"`
+	Id                 SurveyId              `json:"id"`
+	Title              string                `json:"title"`
+	Description        *string               `json:"description"`
+	MaxParticipants    int                   `json:"maxParticipants"`
+	EndTime            time.Time             `json:"endTime"`
+	Questions          []Question            `json:"questions"`
+	SurveyStatus       SurveyStatus          `json:"surveyStatus"`
+	TenantId           string                `json:"tenantId"`
+	OwnerId            string                `json:"ownerId"`
+	Collaborators      []collaboratorData    `json:"collaborators"`
+	AnonymityMode      AnonymityMode         `json:"anonymityMode"`
+	InvitationRequired bool                  `json:"invitationRequired"`
+	SubmissionTimes    []time.Time           `json:"submissionTimes"`
+	Reservations       []slotReservationData `json:"reservations"`
+	Version            int                   `json:"version"`
+	CreatedAt          time.Time             `json:"createdAt"`
+}
+
+type collaboratorData struct {
+	UserId     string                 `json:"userId"`
+	Permission CollaboratorPermission `json:"permission"`
+}
+
+type slotReservationData struct {
+	ResponseId SurveyResponseId `json:"responseId"`
+	ExpiresAt  time.Time        `json:"expiresAt"`
+}
+
+func (s Survey) MarshalJSON() ([]byte, error) {
+	collaborators := make([]collaboratorData, 0, len(s.Collaborators))
+	for _, c := range s.Collaborators {
+		collaborators = append(collaborators, collaboratorData(c))
+	}
+
+	reservations := make([]slotReservationData, 0, len(s.Reservations))
+	for _, r := range s.Reservations {
+		reservations = append(reservations, slotReservationData(r))
+	}
+
+	return json.Marshal(surveySnapshot{
+		SchemaVersion:      snapshotSchemaVersion,
+		Id:                 s.Id,
+		Title:              s.Title,
+		Description:        s.Description,
+		MaxParticipants:    s.MaxParticipants,
+		EndTime:            s.EndTime,
+		Questions:          s.Questions,
+		SurveyStatus:       s.SurveyStatus,
+		TenantId:           s.TenantId,
+		OwnerId:            s.OwnerId,
+		Collaborators:      collaborators,
+		AnonymityMode:      s.AnonymityMode,
+		InvitationRequired: s.InvitationRequired,
+		SubmissionTimes:    s.SubmissionTimes,
+		Reservations:       reservations,
+		Version:            s.Version(),
+		CreatedAt:          s.CreatedAt(),
+	})
+}
+
+func (s *Survey) UnmarshalJSON(data []byte) error {
+	var snapshot surveySnapshot
+
+	err := json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = checkSnapshotVersion("survey", snapshot.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	collaborators := make([]Collaborator, 0, len(snapshot.Collaborators))
+	for _, c := range snapshot.Collaborators {
+		collaborators = append(collaborators, Collaborator(c))
+	}
+
+	reservations := make([]SlotReservation, 0, len(snapshot.Reservations))
+	for _, r := range snapshot.Reservations {
+		reservations = append(reservations, SlotReservation(r))
+	}
+
+	s.Id = snapshot.Id
+	s.Title = snapshot.Title
+	s.Description = snapshot.Description
+	s.MaxParticipants = snapshot.MaxParticipants
+	s.EndTime = snapshot.EndTime
+	s.Questions = snapshot.Questions
+	s.SurveyStatus = snapshot.SurveyStatus
+	s.TenantId = snapshot.TenantId
+	s.OwnerId = snapshot.OwnerId
+	s.Collaborators = collaborators
+	s.AnonymityMode = snapshot.AnonymityMode
+	s.InvitationRequired = snapshot.InvitationRequired
+	s.SubmissionTimes = snapshot.SubmissionTimes
+	s.Reservations = reservations
+	s.SetVersion(snapshot.Version)
+	s.SetCreatedAt(snapshot.CreatedAt)
+
+	return nil
+}
+
+// questionData is the stored and published form of a question, used both in
+// survey snapshots and in QuestionAdded events.
+type questionData struct {
+	Id              QuestionId           `json:"id"`
+	Title           string               `json:"title"`
+	Description     *string              `json:"description"`
+	QuestionType    QuestionType         `json:"questionType"`
+	QuestionOptions []questionOptionData `json:"questionOptions"`
+}
+
+type questionOptionData struct {
+	Id    QuestionOptionId `json:"id"`
+	Value string           `json:"value"`
+}
+
+func (q Question) MarshalJSON() ([]byte, error) {
+	options := make([]questionOptionData, 0, len(q.QuestionOptions))
+	for _, o := range q.QuestionOptions {
+		options = append(options, questionOptionData(o))
+	}
+
+	return json.Marshal(questionData{
+		Id:              q.Id,
+		Title:           q.Title,
+		Description:     q.Description,
+		QuestionType:    q.QuestionType,
+		QuestionOptions: options,
+	})
+}
+
+func (q *Question) UnmarshalJSON(data []byte) error {
+	var question questionData
+
+	err := json.Unmarshal(data, &question)
+	if err != nil {
+		return err
+	}
+
+	options := make([]QuestionOption, 0, len(question.QuestionOptions))
+	for _, o := range question.QuestionOptions {
+		options = append(options, QuestionOption(o))
+	}
+
+	q.Id = question.Id
+	q.Title = question.Title
+	q.Description = question.Description
+	q.QuestionType = question.QuestionType
+	q.QuestionOptions = options
+
+	return nil
+}
+
+type surveyResponseSnapshot struct {
+	SchemaVersion     int                    `json:"schemaVersion"`
+	Id                SurveyResponseId       `json:"id"`
+	SurveyId          SurveyId               `json:"surveyId"`
+	RespondentId      RespondentId           `json:"respondentId"`
+	NumberOfQuestions int                    `json:"numberOfQuestions"`
+	Responses         []questionResponseData `json:"responses"`
+	Status            ResponseStatus         `json:"status"`
+	Version           int                    `json:"version"`
+	CreatedAt         time.Time              `json:"createdAt"`
+}
+
+type questionResponseData struct {
+	QuestionId QuestionId         `json:"questionId"`
+	Choices    []QuestionOptionId `json:"choices"`
+}
+
+func (s SurveyResponse) MarshalJSON() ([]byte, error) {
+	responses := make([]questionResponseData, 0, len(s.Responses))
+	for _, r := range s.Responses {
+		responses = append(responses, questionResponseData(r))
+	}
+
+	return json.Marshal(surveyResponseSnapshot{
+		SchemaVersion:     snapshotSchemaVersion,
+		Id:                s.Id,
+		SurveyId:          s.SurveyId,
+		RespondentId:      s.RespondentId,
+		NumberOfQuestions: s.NumberOfQuestions,
+		Responses:         responses,
+		Status:            s.Status,
+		Version:           s.Version(),
+		CreatedAt:         s.CreatedAt(),
+	})
+}
+
+func (s *SurveyResponse) UnmarshalJSON(data []byte) error {
+	var snapshot surveyResponseSnapshot
+
+	err := json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = checkSnapshotVersion("survey response", snapshot.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	responses := make([]QuestionResponse, 0, len(snapshot.Responses))
+	for _, r := range snapshot.Responses {
+		responses = append(responses, QuestionResponse(r))
+	}
+
+	s.Id = snapshot.Id
+	s.SurveyId = snapshot.SurveyId
+	s.RespondentId = snapshot.RespondentId
+	s.NumberOfQuestions = snapshot.NumberOfQuestions
+	s.Responses = responses
+	s.Status = snapshot.Status
+	s.SetVersion(snapshot.Version)
+	s.SetCreatedAt(snapshot.CreatedAt)
+
+	return nil
+}
+
+type invitationSnapshot struct {
+	SchemaVersion    int              `json:"schemaVersion"`
+	Id               InvitationId     `json:"id"`
+	SurveyId         SurveyId         `json:"surveyId"`
+	TokenHash        string           `json:"tokenHash"`
+	ExpiresAt        time.Time        `json:"expiresAt"`
+	InvitationStatus InvitationStatus `json:"invitationStatus"`
+	RedeemedAt       time.Time        `json:"redeemedAt"`
+	ResponseId       SurveyResponseId `json:"responseId"`
+	Version          int              `json:"version"`
+	CreatedAt        time.Time        `json:"createdAt"`
+}
+
+func (i Invitation) MarshalJSON() ([]byte, error) {
+	return json.Marshal(invitationSnapshot{
+		SchemaVersion:    snapshotSchemaVersion,
+		Id:               i.Id,
+		SurveyId:         i.SurveyId,
+		TokenHash:        i.TokenHash,
+		ExpiresAt:        i.ExpiresAt,
+		InvitationStatus: i.InvitationStatus,
+		RedeemedAt:       i.RedeemedAt,
+		ResponseId:       i.ResponseId,
+		Version:          i.Version(),
+		CreatedAt:        i.CreatedAt(),
+	})
+}
+
+func (i *Invitation) UnmarshalJSON(data []byte) error {
+	var snapshot invitationSnapshot
+
+	err := json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = checkSnapshotVersion("invitation", snapshot.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	i.Id = snapshot.Id
+	i.SurveyId = snapshot.SurveyId
+	i.TokenHash = snapshot.TokenHash
+	i.ExpiresAt = snapshot.ExpiresAt
+	i.InvitationStatus = snapshot.InvitationStatus
+	i.RedeemedAt = snapshot.RedeemedAt
+	i.ResponseId = snapshot.ResponseId
+	i.SetVersion(snapshot.Version)
+	i.SetCreatedAt(snapshot.CreatedAt)
+
+	return nil
+}
+
+type submissionSagaSnapshot struct {
+	SchemaVersion int                  `json:"schemaVersion"`
+	ResponseId    SurveyResponseId     `json:"responseId"`
+	SurveyId      SurveyId             `json:"surveyId"`
+	Status        SubmissionSagaStatus `json:"status"`
+	Reason        string               `json:"reason"`
+	Version       int                  `json:"version"`
+	CreatedAt     time.Time            `json:"createdAt"`
+}
+
+func (s SubmissionSaga) MarshalJSON() ([]byte, error) {
+	return json.Marshal(submissionSagaSnapshot{
+		SchemaVersion: snapshotSchemaVersion,
+		ResponseId:    s.ResponseId,
+		SurveyId:      s.SurveyId,
+		Status:        s.Status,
+		Reason:        s.Reason,
+		Version:       s.Version(),
+		CreatedAt:     s.CreatedAt(),
+	})
+}
+
+func (s *SubmissionSaga) UnmarshalJSON(data []byte) error {
+	var snapshot submissionSagaSnapshot
+
+	err := json.Unmarshal(data, &snapshot)
+	if err != nil {
+		return err
+	}
+
+	err = checkSnapshotVersion("submission saga", snapshot.SchemaVersion)
+	if err != nil {
+		return err
+	}
+
+	s.ResponseId = snapshot.ResponseId
+	s.SurveyId = snapshot.SurveyId
+	s.Status = snapshot.Status
+	s.Reason = snapshot.Reason
+	s.SetVersion(snapshot.Version)
+	s.SetCreatedAt(snapshot.CreatedAt)
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package surveys_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurveySnapshot(t *testing.T) {
+	t.Run("stored form of a survey doesn't change", func(t *testing.T) {
+		registry := core.NewEventRegistry()
+		surveys.RegisterEvents(registry)
+
+		survey := new(surveys.Survey)
+		for _, event := range loadFixture(t, registry, "testdata/v1/survey.json") {
+			survey.ApplyEvent(event)
+		}
+		survey.SetVersion(6)
+
+		golden, err := os.ReadFile("testdata/snapshots/survey.json")
+		assert.Nil(t, err)
+
+		data, err := json.Marshal(survey)
+		assert.Nil(t, err)
+		assert.JSONEq(t, string(golden), string(data))
+	})
+
+	t.Run("survey survives a round trip", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		_ = survey.AddCollaborator("editor", surveys.PermissionEdit)
+		_ = survey.ReserveSlot(surveys.NewSurveyResponseId(), time.Now(), time.Minute)
+		survey.SetVersion(4)
+
+		data, err := json.Marshal(survey)
+		assert.Nil(t, err)
+
+		loaded := new(surveys.Survey)
+		err = json.Unmarshal(data, loaded)
+		assert.Nil(t, err)
+
+		assert.Equal(t, survey.Id, loaded.Id)
+		assert.Equal(t, survey.OwnerId, loaded.OwnerId)
+		assert.Equal(t, survey.Collaborators, loaded.Collaborators)
+		assert.Len(t, loaded.Reservations, 1)
+		assert.Equal(t, 4, loaded.Version())
+		assert.True(t, survey.CreatedAt().Equal(loaded.CreatedAt()))
+	})
+
+	t.Run("reads snapshots stored with Go field names", func(t *testing.T) {
+		legacy := `{
+			"Id": "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f",
+			"Title": "Team satisfaction",
+			"MaxParticipants": 10,
+			"SurveyStatus": "released",
+			"TenantId": "tenant",
+			"Collaborators": [{"UserId": "editor", "Permission": "edit"}]
+		}`
+
+		survey := new(surveys.Survey)
+		err := json.Unmarshal([]byte(legacy), survey)
+		assert.Nil(t, err)
+
+		assert.Equal(t, "Team satisfaction", survey.Title)
+		assert.Equal(t, 10, survey.MaxParticipants)
+		assert.Equal(t, surveys.Released, survey.Status())
+		assert.Equal(t, []surveys.Collaborator{{UserId: "editor", Permission: surveys.PermissionEdit}}, survey.Collaborators)
+	})
+
+	t.Run("rejects snapshots from a newer schema", func(t *testing.T) {
+		survey := new(surveys.Survey)
+		err := json.Unmarshal([]byte(`{"schemaVersion": 99}`), survey)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestUpcastToTaggedPayloads(t *testing.T) {
+	registry := core.NewEventRegistry()
+	surveys.RegisterEvents(registry)
+
+	t.Run("v1 payloads are upcast to camelCase fields", func(t *testing.T) {
+		payload, err := registry.Upcast("question-added", 1, []byte(`{
+			"Id": "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f",
+			"Question": {"Id": "q", "Title": "t", "QuestionOptions": [{"Id": "o", "Value": "v"}]}
+		}`))
+		assert.Nil(t, err)
+
+		assert.JSONEq(t, `{
+			"id": "6f1c2d3e-4b5a-4c7d-8e9f-0a1b2c3d4e5f",
+			"question": {"id": "q", "title": "t", "questionOptions": [{"id": "o", "value": "v"}]}
+		}`, string(payload))
+	})
+}
@@ -0,0 +1,102 @@
+package surveys
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+type SubmissionSagaStatus string
+
+const (
+	SubmissionSagaStatusPending  SubmissionSagaStatus = "pending"
+	SubmissionSagaStatusAccepted SubmissionSagaStatus = "accepted"
+	SubmissionSagaStatusRejected SubmissionSagaStatus = "rejected"
+)
+
+// SubmissionSaga tracks a submitted response until the survey has counted
+// it, or the response has been rejected because the survey couldn't accept
+// it. It shares its id with the response.
+type SubmissionSaga struct {
+	ResponseId SurveyResponseId
+	SurveyId   SurveyId
+	Status     SubmissionSagaStatus
+	Reason     string
+
+	core.BaseAggregate
+}
+
+func NewSubmissionSaga(responseId SurveyResponseId, surveyId SurveyId) *SubmissionSaga {
+	saga := new(SubmissionSaga)
+
+	saga.addEvent(SubmissionSagaStarted{
+		ResponseId: responseId,
+		SurveyId:   surveyId,
+		CreatedAt:  time.Now(),
+	})
+
+	return saga
+}
+
+func (s SubmissionSaga) ID() core.AggregateId {
+	return core.AggregateId(s.ResponseId)
+}
+
+func (s SubmissionSaga) Name() string {
+	return "submission-saga"
+}
+
+func (s SubmissionSaga) TableName() string {
+	return "submission_sagas"
+}
+
+func (s *SubmissionSaga) Accept() error {
+	if s.Status != SubmissionSagaStatusPending {
+		return errors.New("submission saga has already finished")
+	}
+
+	s.addEvent(SubmissionSagaAccepted{
+		ResponseId: s.ResponseId,
+		CreatedAt:  time.Now(),
+	})
+
+	return nil
+}
+
+func (s *SubmissionSaga) Reject(reason string) error {
+	if s.Status != SubmissionSagaStatusPending {
+		return errors.New("submission saga has already finished")
+	}
+
+	s.addEvent(SubmissionSagaRejected{
+		ResponseId: s.ResponseId,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+
+	return nil
+}
+
+func (s *SubmissionSaga) ApplyEvent(event core.DomainEvent) {
+	switch e := event.(type) {
+	case SubmissionSagaStarted:
+		s.ResponseId = e.ResponseId
+		s.SurveyId = e.SurveyId
+		s.Status = SubmissionSagaStatusPending
+		s.SetCreatedAt(e.CreatedAt)
+	case SubmissionSagaAccepted:
+		s.Status = SubmissionSagaStatusAccepted
+	case SubmissionSagaRejected:
+		s.Status = SubmissionSagaStatusRejected
+		s.Reason = e.Reason
+	default:
+		panic(fmt.Sprintf("unknown event: %+v", e))
+	}
+}
+
+func (s *SubmissionSaga) addEvent(event core.DomainEvent) {
+	s.AddDomainEvent(event)
+	s.ApplyEvent(event)
+}
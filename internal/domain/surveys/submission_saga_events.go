@@ -0,0 +1,60 @@
+package surveys
+
+import (
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+)
+
+type SubmissionSagaStarted struct {
+	ResponseId SurveyResponseId
+	SurveyId   SurveyId
+	CreatedAt  time.Time
+}
+
+func (e SubmissionSagaStarted) AggregateId() core.AggregateId {
+	return core.AggregateId(e.ResponseId)
+}
+
+func (e SubmissionSagaStarted) Type() string {
+	return "submission-saga-started"
+}
+
+func (e SubmissionSagaStarted) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type SubmissionSagaAccepted struct {
+	ResponseId SurveyResponseId
+	CreatedAt  time.Time
+}
+
+func (e SubmissionSagaAccepted) AggregateId() core.AggregateId {
+	return core.AggregateId(e.ResponseId)
+}
+
+func (e SubmissionSagaAccepted) Type() string {
+	return "submission-saga-accepted"
+}
+
+func (e SubmissionSagaAccepted) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type SubmissionSagaRejected struct {
+	ResponseId SurveyResponseId
+	Reason     string
+	CreatedAt  time.Time
+}
+
+func (e SubmissionSagaRejected) AggregateId() core.AggregateId {
+	return core.AggregateId(e.ResponseId)
+}
+
+func (e SubmissionSagaRejected) Type() string {
+	return "submission-saga-rejected"
+}
+
+func (e SubmissionSagaRejected) OccurredAt() time.Time {
+	return e.CreatedAt
+}
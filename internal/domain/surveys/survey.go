@@ -1,15 +1,24 @@
 package surveys
 
 import (
+	"crypto/sha256"
 	"database/sql/driver"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/markusryoti/survey-ddd/internal/core"
 )
 
+var (
+	ErrNoSlotsAvailable   = errors.New("no participant slots available")
+	ErrSlotNotReserved    = errors.New("no slot reserved for response")
+	ErrInvitationRequired = errors.New("survey can only be answered with an invitation")
+)
+
 type SurveyId core.AggregateId
 
 func NewSurveyId() SurveyId {
@@ -54,27 +63,36 @@ type Survey struct {
 	Questions       []Question
 	SurveyStatus    SurveyStatus
 	TenantId        string
-	SubmissionTimes []time.Time
+	OwnerId         string
+	Collaborators   []Collaborator
+	AnonymityMode   AnonymityMode
+	// InvitationRequired restricts responses to respondents holding an
+	// invitation to the survey.
+	InvitationRequired bool
+	SubmissionTimes    []time.Time
+	Reservations       []SlotReservation
 
 	core.BaseAggregate
 }
 
-func NewSurvey(title string, description *string, tenantId string) (*Survey, error) {
+func NewSurvey(title string, description *string, tenantId string, ownerId string) (*Survey, error) {
 	now := time.Now()
 
-	if title == "" || tenantId == "" {
+	if title == "" || tenantId == "" || ownerId == "" {
 		return nil, errors.New("invalid survey")
 	}
 
 	survey := new(Survey)
 
 	survey.addEvent(SurveyCreated{
-		Id:           NewSurveyId(),
-		Title:        title,
-		Description:  description,
-		TenantId:     tenantId,
-		SurveyStatus: Draft,
-		CreatedAt:    now,
+		Id:            NewSurveyId(),
+		Title:         title,
+		Description:   description,
+		TenantId:      tenantId,
+		OwnerId:       ownerId,
+		SurveyStatus:  Draft,
+		AnonymityMode: Anonymous,
+		CreatedAt:     now,
 	})
 
 	return survey, nil
@@ -101,6 +119,57 @@ const (
 	Completed SurveyStatus = "completed"
 )
 
+// AnonymityMode decides what is stored about the person answering a survey.
+type AnonymityMode string
+
+const (
+	// Anonymous responses carry no respondent identity at all.
+	Anonymous AnonymityMode = "anonymous"
+	// Pseudonymous responses are identified by a hash of the invitation the
+	// respondent was sent, so an invitation can't be used to answer twice.
+	Pseudonymous AnonymityMode = "pseudonymous"
+	// Identified responses are tied to the authenticated user.
+	Identified AnonymityMode = "identified"
+)
+
+func NewAnonymityMode(mode string) (AnonymityMode, error) {
+	switch m := AnonymityMode(mode); m {
+	case Anonymous, Pseudonymous, Identified:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid anonymity mode: %s", mode)
+	}
+}
+
+type RespondentId string
+
+// SlotReservation is a participant slot held by a started response.
+type SlotReservation struct {
+	ResponseId SurveyResponseId
+	ExpiresAt  time.Time
+}
+
+type CollaboratorPermission string
+
+const (
+	PermissionEdit        CollaboratorPermission = "edit"
+	PermissionViewResults CollaboratorPermission = "view-results"
+)
+
+func NewCollaboratorPermission(permission string) (CollaboratorPermission, error) {
+	switch p := CollaboratorPermission(permission); p {
+	case PermissionEdit, PermissionViewResults:
+		return p, nil
+	default:
+		return "", fmt.Errorf("invalid collaborator permission: %s", permission)
+	}
+}
+
+type Collaborator struct {
+	UserId     string
+	Permission CollaboratorPermission
+}
+
 type QuestionId string
 
 type Question struct {
@@ -175,6 +244,108 @@ func (s *Survey) AddQuestion(question Question) {
 	})
 }
 
+func (s *Survey) SetAnonymityMode(mode AnonymityMode) error {
+	if s.SurveyStatus != Draft {
+		return errors.New("anonymity mode can only be changed on a draft survey")
+	}
+
+	s.addEvent(AnonymityModeChanged{
+		Id:            s.Id,
+		AnonymityMode: mode,
+		CreatedAt:     time.Now(),
+	})
+
+	return nil
+}
+
+// RequireInvitation decides whether the survey can only be answered with an
+// invitation.
+func (s *Survey) RequireInvitation(required bool) error {
+	if s.SurveyStatus != Draft {
+		return errors.New("invitation requirement can only be changed on a draft survey")
+	}
+
+	s.addEvent(InvitationRequirementChanged{
+		Id:                 s.Id,
+		InvitationRequired: required,
+		CreatedAt:          time.Now(),
+	})
+
+	return nil
+}
+
+// RespondentFor resolves the identity a response is recorded with. Anonymous
+// surveys return an empty id, meaning responses can't be told apart.
+// Pseudonymous respondents are identified by their invitation, which the
+// caller has to redeem for the response.
+func (s Survey) RespondentFor(userId string, invitation *Invitation) (RespondentId, error) {
+	switch s.AnonymityMode {
+	case Pseudonymous:
+		if invitation == nil {
+			return "", errors.New("pseudonymous responses require an invitation")
+		}
+		if invitation.SurveyId != s.Id {
+			return "", ErrInvalidInvitationToken
+		}
+		sum := sha256.Sum256([]byte(s.Id.String() + ":" + invitation.Id.String()))
+		return RespondentId("invitation:" + hex.EncodeToString(sum[:])), nil
+	case Identified:
+		if userId == "" {
+			return "", errors.New("respondent must be identified")
+		}
+		return RespondentId("user:" + userId), nil
+	default:
+		return "", nil
+	}
+}
+
+func (s *Survey) AddCollaborator(userId string, permission CollaboratorPermission) error {
+	if userId == "" {
+		return errors.New("collaborator needs a user")
+	}
+
+	if userId == s.OwnerId {
+		return errors.New("owner can't be added as a collaborator")
+	}
+
+	if current, ok := s.CollaboratorPermission(userId); ok && current == permission {
+		return errors.New("user is already a collaborator")
+	}
+
+	s.addEvent(CollaboratorAdded{
+		Id:         s.Id,
+		UserId:     userId,
+		Permission: permission,
+		CreatedAt:  time.Now(),
+	})
+
+	return nil
+}
+
+func (s *Survey) RemoveCollaborator(userId string) error {
+	if _, ok := s.CollaboratorPermission(userId); !ok {
+		return errors.New("collaborator not found")
+	}
+
+	s.addEvent(CollaboratorRemoved{
+		Id:        s.Id,
+		UserId:    userId,
+		CreatedAt: time.Now(),
+	})
+
+	return nil
+}
+
+func (s Survey) CollaboratorPermission(userId string) (CollaboratorPermission, bool) {
+	for _, c := range s.Collaborators {
+		if c.UserId == userId {
+			return c.Permission, true
+		}
+	}
+
+	return "", false
+}
+
 func (s *Survey) Release(now time.Time) error {
 	if s.MaxParticipants == 0 {
 		return errors.New("can't release without number of participants")
@@ -220,24 +391,155 @@ func (s *Survey) getQuestion(id QuestionId) (Question, error) {
 }
 
 func (s *Survey) SubmissionReceived(receivedAt time.Time) error {
-	if s.SurveyStatus == Draft {
-		return errors.New("can't add a submission to a draft survey")
+	return s.submissionReceived(SurveyResponseId{}, receivedAt)
+}
+
+// AcceptSubmission records the submission of a response, confirming the slot
+// the response has reserved or taking a free one if it hasn't.
+func (s *Survey) AcceptSubmission(responseId SurveyResponseId, receivedAt time.Time) error {
+	if _, ok := s.reservation(responseId); ok {
+		return s.ConfirmSlot(responseId, receivedAt)
 	}
 
-	if s.SurveyStatus == Locked {
-		return errors.New("can't add a submission to locked survey")
+	return s.submissionReceived(responseId, receivedAt)
+}
+
+func (s *Survey) submissionReceived(responseId SurveyResponseId, receivedAt time.Time) error {
+	err := s.acceptsSubmissions(receivedAt)
+	if err != nil {
+		return err
 	}
 
-	if s.AnswersReceived() >= s.MaxParticipants {
-		return fmt.Errorf("number of participants (%d) exceeded", s.MaxParticipants)
+	if s.AnswersReceived()+s.ActiveReservations(receivedAt) >= s.MaxParticipants {
+		return fmt.Errorf("%w: number of participants (%d) exceeded", ErrNoSlotsAvailable, s.MaxParticipants)
+	}
+
+	s.recordSubmission(responseId, receivedAt)
+
+	return nil
+}
+
+// ReserveSlot holds a participant slot for a response that has been started.
+// The slot counts towards the participant limit until it is confirmed or
+// released after the ttl has passed.
+func (s *Survey) ReserveSlot(responseId SurveyResponseId, now time.Time, ttl time.Duration) error {
+	err := s.acceptsSubmissions(now)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := s.reservation(responseId); ok {
+		return errors.New("response already holds a slot")
+	}
+
+	if s.AnswersReceived()+s.ActiveReservations(now) >= s.MaxParticipants {
+		return fmt.Errorf("%w: number of participants (%d) exceeded", ErrNoSlotsAvailable, s.MaxParticipants)
+	}
+
+	s.addEvent(SlotReserved{
+		Id:         s.Id,
+		ResponseId: responseId,
+		ExpiresAt:  now.Add(ttl),
+		CreatedAt:  now,
+	})
+
+	return nil
+}
+
+// ConfirmSlot turns the slot reserved for the response into a submission.
+// A reservation that has already expired, or been released because of it,
+// is only honored if there is still room for it.
+func (s *Survey) ConfirmSlot(responseId SurveyResponseId, receivedAt time.Time) error {
+	err := s.acceptsSubmissions(receivedAt)
+	if err != nil {
+		return err
+	}
+
+	reservation, ok := s.reservation(responseId)
+	if !ok {
+		if s.AnswersReceived()+s.ActiveReservations(receivedAt) >= s.MaxParticipants {
+			return fmt.Errorf("%w: %w", ErrNoSlotsAvailable, ErrSlotNotReserved)
+		}
+
+		s.recordSubmission(responseId, receivedAt)
+
+		return nil
+	}
+
+	if !receivedAt.Before(reservation.ExpiresAt) &&
+		s.AnswersReceived()+s.ActiveReservations(receivedAt) >= s.MaxParticipants {
+		return fmt.Errorf("%w: slot reservation has expired", ErrNoSlotsAvailable)
+	}
+
+	s.recordSubmission(responseId, receivedAt)
+
+	return nil
+}
+
+// ReleaseExpiredSlots frees the slots whose reservation has expired.
+func (s *Survey) ReleaseExpiredSlots(now time.Time) {
+	expired := make([]SurveyResponseId, 0)
+
+	for _, r := range s.Reservations {
+		if !now.Before(r.ExpiresAt) {
+			expired = append(expired, r.ResponseId)
+		}
+	}
+
+	for _, responseId := range expired {
+		s.addEvent(SlotReleased{
+			Id:         s.Id,
+			ResponseId: responseId,
+			CreatedAt:  now,
+		})
+	}
+}
+
+// ActiveReservations returns the number of reserved slots that haven't
+// expired yet.
+func (s Survey) ActiveReservations(now time.Time) int {
+	active := 0
+
+	for _, r := range s.Reservations {
+		if now.Before(r.ExpiresAt) {
+			active++
+		}
+	}
+
+	return active
+}
+
+func (s Survey) reservation(responseId SurveyResponseId) (SlotReservation, bool) {
+	for _, r := range s.Reservations {
+		if r.ResponseId == responseId {
+			return r, true
+		}
+	}
+
+	return SlotReservation{}, false
+}
+
+func (s Survey) acceptsSubmissions(receivedAt time.Time) error {
+	switch s.SurveyStatus {
+	case Draft:
+		return errors.New("can't add a submission to a draft survey")
+	case Locked:
+		return errors.New("can't add a submission to locked survey")
+	case Completed:
+		return fmt.Errorf("%w: survey has been completed", ErrNoSlotsAvailable)
 	}
 
 	if s.EndTime.Before(receivedAt) {
 		return errors.New("end time for survey has passed")
 	}
 
+	return nil
+}
+
+func (s *Survey) recordSubmission(responseId SurveyResponseId, receivedAt time.Time) {
 	s.addEvent(SubmissionReceived{
 		Id:         s.Id,
+		ResponseId: responseId,
 		ReceivedAt: receivedAt,
 		CreatedAt:  time.Now(),
 	})
@@ -248,8 +550,6 @@ func (s *Survey) SubmissionReceived(receivedAt time.Time) error {
 			CreatedAt: time.Now(),
 		})
 	}
-
-	return nil
 }
 
 func (s *Survey) Lock() {
@@ -270,27 +570,59 @@ func (s *Survey) ApplyEvent(event core.DomainEvent) {
 		s.Title = e.Title
 		s.Description = e.Description
 		s.TenantId = e.TenantId
+		s.OwnerId = e.OwnerId
 		s.SurveyStatus = e.SurveyStatus
+		s.AnonymityMode = e.AnonymityMode
 		s.SetCreatedAt(e.CreatedAt)
 	case QuestionAdded:
 		s.Questions = append(s.Questions, e.Question)
+	case AnonymityModeChanged:
+		s.AnonymityMode = e.AnonymityMode
+	case InvitationRequirementChanged:
+		s.InvitationRequired = e.InvitationRequired
 	case MaxParticipantsChanged:
 		s.MaxParticipants = e.MaxParticipants
 	case SurveyEndTimeChanged:
 		s.EndTime = e.EndTime
 	case SurveyReleased:
 		s.SurveyStatus = Released
+	case SlotReserved:
+		s.Reservations = append(s.Reservations, SlotReservation{
+			ResponseId: e.ResponseId,
+			ExpiresAt:  e.ExpiresAt,
+		})
+	case SlotReleased:
+		s.removeReservation(e.ResponseId)
 	case SubmissionReceived:
 		s.SubmissionTimes = append(s.SubmissionTimes, e.ReceivedAt)
+		s.removeReservation(e.ResponseId)
 	case SurveyCompleted:
 		s.SurveyStatus = Completed
 	case SurveyLocked:
 		s.SurveyStatus = Locked
+	case CollaboratorAdded:
+		s.Collaborators = slices.DeleteFunc(s.Collaborators, func(c Collaborator) bool {
+			return c.UserId == e.UserId
+		})
+		s.Collaborators = append(s.Collaborators, Collaborator{
+			UserId:     e.UserId,
+			Permission: e.Permission,
+		})
+	case CollaboratorRemoved:
+		s.Collaborators = slices.DeleteFunc(s.Collaborators, func(c Collaborator) bool {
+			return c.UserId == e.UserId
+		})
 	default:
 		panic(fmt.Sprintf("unknown event: %+v", e))
 	}
 }
 
+func (s *Survey) removeReservation(responseId SurveyResponseId) {
+	s.Reservations = slices.DeleteFunc(s.Reservations, func(r SlotReservation) bool {
+		return r.ResponseId == responseId
+	})
+}
+
 func (s *Survey) addEvent(event core.DomainEvent) {
 	s.AddDomainEvent(event)
 	s.ApplyEvent(event)
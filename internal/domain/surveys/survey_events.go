@@ -7,12 +7,14 @@ import (
 )
 
 type SurveyCreated struct {
-	Id           SurveyId
-	Title        string
-	Description  *string
-	TenantId     string
-	SurveyStatus SurveyStatus
-	CreatedAt    time.Time
+	Id            SurveyId
+	Title         string
+	Description   *string
+	TenantId      string
+	OwnerId       string
+	SurveyStatus  SurveyStatus
+	AnonymityMode AnonymityMode
+	CreatedAt     time.Time
 }
 
 func (e SurveyCreated) AggregateId() core.AggregateId {
@@ -45,6 +47,42 @@ func (e QuestionAdded) OccurredAt() time.Time {
 	return e.CreatedAt
 }
 
+type AnonymityModeChanged struct {
+	Id            SurveyId
+	AnonymityMode AnonymityMode
+	CreatedAt     time.Time
+}
+
+func (e AnonymityModeChanged) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e AnonymityModeChanged) Type() string {
+	return "anonymity-mode-changed"
+}
+
+func (e AnonymityModeChanged) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type InvitationRequirementChanged struct {
+	Id                 SurveyId
+	InvitationRequired bool
+	CreatedAt          time.Time
+}
+
+func (e InvitationRequirementChanged) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e InvitationRequirementChanged) Type() string {
+	return "invitation-requirement-changed"
+}
+
+func (e InvitationRequirementChanged) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
 type MaxParticipantsChanged struct {
 	Id              SurveyId
 	MaxParticipants int
@@ -100,6 +138,7 @@ func (e SurveyReleased) OccurredAt() time.Time {
 
 type SubmissionReceived struct {
 	Id         SurveyId
+	ResponseId SurveyResponseId
 	ReceivedAt time.Time
 	CreatedAt  time.Time
 }
@@ -116,6 +155,43 @@ func (e SubmissionReceived) OccurredAt() time.Time {
 	return e.CreatedAt
 }
 
+type SlotReserved struct {
+	Id         SurveyId
+	ResponseId SurveyResponseId
+	ExpiresAt  time.Time
+	CreatedAt  time.Time
+}
+
+func (e SlotReserved) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e SlotReserved) Type() string {
+	return "slot-reserved"
+}
+
+func (e SlotReserved) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type SlotReleased struct {
+	Id         SurveyId
+	ResponseId SurveyResponseId
+	CreatedAt  time.Time
+}
+
+func (e SlotReleased) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e SlotReleased) Type() string {
+	return "slot-released"
+}
+
+func (e SlotReleased) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
 type SurveyCompleted struct {
 	Id        SurveyId
 	CreatedAt time.Time
@@ -149,3 +225,40 @@ func (e SurveyLocked) Type() string {
 func (e SurveyLocked) OccurredAt() time.Time {
 	return e.CreatedAt
 }
+
+type CollaboratorAdded struct {
+	Id         SurveyId
+	UserId     string
+	Permission CollaboratorPermission
+	CreatedAt  time.Time
+}
+
+func (e CollaboratorAdded) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e CollaboratorAdded) Type() string {
+	return "collaborator-added"
+}
+
+func (e CollaboratorAdded) OccurredAt() time.Time {
+	return e.CreatedAt
+}
+
+type CollaboratorRemoved struct {
+	Id        SurveyId
+	UserId    string
+	CreatedAt time.Time
+}
+
+func (e CollaboratorRemoved) AggregateId() core.AggregateId {
+	return core.AggregateId(e.Id)
+}
+
+func (e CollaboratorRemoved) Type() string {
+	return "collaborator-removed"
+}
+
+func (e CollaboratorRemoved) OccurredAt() time.Time {
+	return e.CreatedAt
+}
@@ -12,7 +12,7 @@ func TestNewSurvey(t *testing.T) {
 	t.Run("new survey is created and in draft state", func(t *testing.T) {
 		title := "a title"
 		description := "a description"
-		survey, err := surveys.NewSurvey(title, &description, "tenant")
+		survey, err := surveys.NewSurvey(title, &description, "tenant", "owner")
 
 		assert.Nil(t, err)
 		assert.Equal(t, title, survey.Title)
@@ -28,6 +28,138 @@ func TestNewSurvey(t *testing.T) {
 	})
 }
 
+func TestAnonymityMode(t *testing.T) {
+	t.Run("new survey is anonymous", func(t *testing.T) {
+		survey := newSurvey()
+		assert.Equal(t, surveys.Anonymous, survey.AnonymityMode)
+
+		respondent, err := survey.RespondentFor("user", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.RespondentId(""), respondent)
+	})
+
+	t.Run("pseudonymous survey requires an invitation", func(t *testing.T) {
+		survey := newSurvey()
+		_ = survey.SetAnonymityMode(surveys.Pseudonymous)
+
+		_, err := survey.RespondentFor("user", nil)
+		assert.NotNil(t, err)
+
+		invitation, _, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+
+		first, err := survey.RespondentFor("", invitation)
+		assert.Nil(t, err)
+		assert.NotContains(t, string(first), invitation.Id.String())
+
+		second, _ := survey.RespondentFor("other", invitation)
+		assert.Equal(t, first, second)
+
+		other, _, _ := surveys.NewInvitation(survey.Id, now().Add(time.Hour), now())
+		third, _ := survey.RespondentFor("", other)
+		assert.NotEqual(t, first, third)
+	})
+
+	t.Run("pseudonymous survey rejects invitations to other surveys", func(t *testing.T) {
+		survey := newSurvey()
+		_ = survey.SetAnonymityMode(surveys.Pseudonymous)
+
+		invitation, _, _ := surveys.NewInvitation(surveys.NewSurveyId(), now().Add(time.Hour), now())
+
+		_, err := survey.RespondentFor("", invitation)
+		assert.ErrorIs(t, err, surveys.ErrInvalidInvitationToken)
+	})
+
+	t.Run("identified survey requires a user", func(t *testing.T) {
+		survey := newSurvey()
+		_ = survey.SetAnonymityMode(surveys.Identified)
+
+		_, err := survey.RespondentFor("", nil)
+		assert.NotNil(t, err)
+
+		respondent, err := survey.RespondentFor("user", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, surveys.RespondentId("user:user"), respondent)
+	})
+
+	t.Run("invitation requirement can only be changed on a draft", func(t *testing.T) {
+		survey := newSurvey()
+		assert.False(t, survey.InvitationRequired)
+
+		assert.Nil(t, survey.RequireInvitation(true))
+		assert.True(t, survey.InvitationRequired)
+
+		survey.SetMaxParticipants(3)
+		_ = survey.Release(now())
+
+		err := survey.RequireInvitation(false)
+		assert.NotNil(t, err)
+		assert.True(t, survey.InvitationRequired)
+	})
+
+	t.Run("can't change anonymity mode after release", func(t *testing.T) {
+		survey := newSurvey()
+		survey.SetMaxParticipants(3)
+		_ = survey.Release(now())
+
+		err := survey.SetAnonymityMode(surveys.Identified)
+		assert.NotNil(t, err)
+	})
+}
+
+func TestCollaborators(t *testing.T) {
+	t.Run("new survey is owned by its creator", func(t *testing.T) {
+		survey := newSurvey()
+		assert.Equal(t, "owner", survey.OwnerId)
+	})
+
+	t.Run("can add and remove a collaborator", func(t *testing.T) {
+		survey := newSurvey()
+
+		err := survey.AddCollaborator("user", surveys.PermissionEdit)
+		assert.Nil(t, err)
+
+		permission, ok := survey.CollaboratorPermission("user")
+		assert.True(t, ok)
+		assert.Equal(t, surveys.PermissionEdit, permission)
+
+		err = survey.RemoveCollaborator("user")
+		assert.Nil(t, err)
+		assert.Len(t, survey.Collaborators, 0)
+	})
+
+	t.Run("adding an existing collaborator changes the permission", func(t *testing.T) {
+		survey := newSurvey()
+
+		_ = survey.AddCollaborator("user", surveys.PermissionEdit)
+		err := survey.AddCollaborator("user", surveys.PermissionViewResults)
+		assert.Nil(t, err)
+
+		assert.Len(t, survey.Collaborators, 1)
+		permission, _ := survey.CollaboratorPermission("user")
+		assert.Equal(t, surveys.PermissionViewResults, permission)
+	})
+
+	t.Run("can't add the same collaborator twice", func(t *testing.T) {
+		survey := newSurvey()
+
+		_ = survey.AddCollaborator("user", surveys.PermissionEdit)
+		err := survey.AddCollaborator("user", surveys.PermissionEdit)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("can't add owner as a collaborator", func(t *testing.T) {
+		survey := newSurvey()
+		err := survey.AddCollaborator("owner", surveys.PermissionEdit)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("can't remove a user who isn't a collaborator", func(t *testing.T) {
+		survey := newSurvey()
+		err := survey.RemoveCollaborator("user")
+		assert.NotNil(t, err)
+	})
+}
+
 func TestReleaseAndLock(t *testing.T) {
 	t.Run("can release survey", func(t *testing.T) {
 		survey := newSurvey()
@@ -151,9 +283,139 @@ func TestSubmissions(t *testing.T) {
 	})
 }
 
+func TestSlotReservations(t *testing.T) {
+	t.Run("reserved slots count towards max participants", func(t *testing.T) {
+		survey := newReleasedSurvey()
+
+		for range 3 {
+			err := survey.ReserveSlot(surveys.NewSurveyResponseId(), now(), time.Minute)
+			assert.Nil(t, err)
+		}
+
+		err := survey.ReserveSlot(surveys.NewSurveyResponseId(), now(), time.Minute)
+		assert.ErrorIs(t, err, surveys.ErrNoSlotsAvailable)
+
+		err = survey.SubmissionReceived(now())
+		assert.ErrorIs(t, err, surveys.ErrNoSlotsAvailable)
+	})
+
+	t.Run("confirming a slot records a submission", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		responseId := surveys.NewSurveyResponseId()
+
+		_ = survey.ReserveSlot(responseId, now(), time.Minute)
+
+		err := survey.ConfirmSlot(responseId, now())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, survey.AnswersReceived())
+		assert.Equal(t, 0, survey.ActiveReservations(now()))
+	})
+
+	t.Run("released slot is confirmed while there is room", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		responseId := surveys.NewSurveyResponseId()
+
+		_ = survey.ReserveSlot(responseId, now(), time.Second)
+
+		later := now().Add(2 * time.Second)
+		survey.ReleaseExpiredSlots(later)
+
+		err := survey.ConfirmSlot(responseId, later)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, survey.AnswersReceived())
+	})
+
+	t.Run("released slot can't be confirmed when survey is full", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		released := surveys.NewSurveyResponseId()
+
+		_ = survey.ReserveSlot(released, now(), time.Second)
+
+		later := now().Add(2 * time.Second)
+		survey.ReleaseExpiredSlots(later)
+
+		for range 3 {
+			_ = survey.ReserveSlot(surveys.NewSurveyResponseId(), later, time.Minute)
+		}
+
+		err := survey.ConfirmSlot(released, later)
+		assert.ErrorIs(t, err, surveys.ErrNoSlotsAvailable)
+		assert.ErrorIs(t, err, surveys.ErrSlotNotReserved)
+	})
+
+	t.Run("can't reserve twice for the same response", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		responseId := surveys.NewSurveyResponseId()
+
+		_ = survey.ReserveSlot(responseId, now(), time.Minute)
+
+		err := survey.ReserveSlot(responseId, now(), time.Minute)
+		assert.NotNil(t, err)
+	})
+
+	t.Run("expired slots are released", func(t *testing.T) {
+		survey := newReleasedSurvey()
+
+		for range 3 {
+			_ = survey.ReserveSlot(surveys.NewSurveyResponseId(), now(), time.Second)
+		}
+
+		later := now().Add(2 * time.Second)
+		assert.Equal(t, 0, survey.ActiveReservations(later))
+
+		survey.ReleaseExpiredSlots(later)
+		assert.Len(t, survey.Reservations, 0)
+
+		err := survey.ReserveSlot(surveys.NewSurveyResponseId(), later, time.Minute)
+		assert.Nil(t, err)
+	})
+
+	t.Run("expired slot can't be confirmed when survey is full", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		expired := surveys.NewSurveyResponseId()
+
+		_ = survey.ReserveSlot(expired, now(), time.Second)
+
+		later := now().Add(2 * time.Second)
+		for range 3 {
+			_ = survey.ReserveSlot(surveys.NewSurveyResponseId(), later, time.Minute)
+		}
+
+		err := survey.ConfirmSlot(expired, later)
+		assert.ErrorIs(t, err, surveys.ErrNoSlotsAvailable)
+	})
+
+	t.Run("survey is completed when the last slot is confirmed", func(t *testing.T) {
+		survey := newReleasedSurvey()
+		responses := []surveys.SurveyResponseId{
+			surveys.NewSurveyResponseId(),
+			surveys.NewSurveyResponseId(),
+			surveys.NewSurveyResponseId(),
+		}
+
+		for _, id := range responses {
+			_ = survey.ReserveSlot(id, now(), time.Minute)
+		}
+
+		for _, id := range responses {
+			err := survey.ConfirmSlot(id, now())
+			assert.Nil(t, err)
+		}
+
+		assert.Equal(t, surveys.Completed, survey.Status())
+	})
+}
+
+func newReleasedSurvey() *surveys.Survey {
+	survey := newSurvey()
+	survey.SetMaxParticipants(3)
+	survey.Release(now())
+	return survey
+}
+
 func newSurvey() *surveys.Survey {
 	description := "a description"
-	survey, _ := surveys.NewSurvey("a title", &description, "tenant")
+	survey, _ := surveys.NewSurvey("a title", &description, "tenant", "owner")
 	survey.SetEndTime(now().Add(1 * time.Minute))
 	return survey
 }
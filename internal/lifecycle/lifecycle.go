@@ -0,0 +1,180 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Component is a part of the application that is started when it starts
+// and stopped when it shuts down. Start must not block. Either function may
+// be nil.
+type Component struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Manager starts components in the order they were added and stops them in
+// the reverse order, so components are stopped before the ones they depend
+// on.
+type Manager struct {
+	components      []Component
+	shutdownTimeout time.Duration
+	logger          *slog.Logger
+	failures        chan error
+}
+
+func NewManager(shutdownTimeout time.Duration, logger *slog.Logger) *Manager {
+	return &Manager{
+		shutdownTimeout: shutdownTimeout,
+		logger:          logger,
+		failures:        make(chan error, 1),
+	}
+}
+
+func (m *Manager) Add(components ...Component) {
+	m.components = append(m.components, components...)
+}
+
+// Run starts the components and blocks until ctx is cancelled or a
+// component fails, then stops the started components. Stopping is given
+// the shutdown timeout in total.
+func (m *Manager) Run(ctx context.Context) error {
+	var started []Component
+	var err error
+
+	for _, c := range m.components {
+		if c.Start != nil {
+			if err = c.Start(ctx); err != nil {
+				err = fmt.Errorf("failed to start %s: %w", c.Name, err)
+				break
+			}
+		}
+		started = append(started, c)
+	}
+
+	if err == nil {
+		m.logger.Info("started")
+
+		select {
+		case <-ctx.Done():
+			m.logger.Info("shutting down")
+		case err = <-m.failures:
+			m.logger.Error("shutting down after failure", slog.String("error", err.Error()))
+		}
+	}
+
+	// Stopping must not be cut short by the cancellation that triggered it.
+	stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.shutdownTimeout)
+	defer cancel()
+
+	errs := []error{err}
+
+	for i := len(started) - 1; i >= 0; i-- {
+		c := started[i]
+		if c.Stop == nil {
+			continue
+		}
+
+		if stopErr := c.Stop(stopCtx); stopErr != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", c.Name, stopErr))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Fail shuts the application down because of err. Only the first failure
+// is reported.
+func (m *Manager) Fail(err error) {
+	select {
+	case m.failures <- err:
+	default:
+	}
+}
+
+// Server serves HTTP until stopped. Stopping closes the listener and waits
+// for in-flight requests to finish.
+func Server(m *Manager, server *http.Server) Component {
+	return Component{
+		Name: "http server",
+		Start: func(ctx context.Context) error {
+			listener, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+
+			go func() {
+				err := server.Serve(listener)
+				if !errors.Is(err, http.ErrServerClosed) {
+					m.Fail(fmt.Errorf("http server: %w", err))
+				}
+			}()
+
+			m.logger.Info("listening", slog.String("addr", listener.Addr().String()))
+
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
+
+// Worker runs fn in the background. Stopping cancels the context given to
+// fn and waits for it to return. Errors returned by fn are logged.
+func Worker(m *Manager, name string, fn func(ctx context.Context) error) Component {
+	var cancel context.CancelFunc
+	var wg sync.WaitGroup
+
+	return Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			var workerCtx context.Context
+			workerCtx, cancel = context.WithCancel(context.WithoutCancel(ctx))
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				err := fn(workerCtx)
+				if err != nil && !errors.Is(err, context.Canceled) {
+					m.logger.Error("worker failed", slog.String("worker", name), slog.String("error", err.Error()))
+				}
+			}()
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+
+			done := make(chan struct{})
+			go func() {
+				wg.Wait()
+				close(done)
+			}()
+
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// Closer closes c when stopped.
+func Closer(name string, c io.Closer) Component {
+	return Component{
+		Name: name,
+		Stop: func(ctx context.Context) error {
+			return c.Close()
+		},
+	}
+}
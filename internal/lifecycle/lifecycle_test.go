@@ -0,0 +1,171 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/lifecycle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManager(t *testing.T) {
+	t.Run("stops components in reverse order", func(t *testing.T) {
+		m := newManager()
+
+		var calls []string
+		for _, name := range []string{"a", "b", "c"} {
+			m.Add(recordingComponent(name, &calls, nil))
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := m.Run(ctx)
+
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"start a", "start b", "start c", "stop c", "stop b", "stop a"}, calls)
+	})
+
+	t.Run("stops started components when one fails to start", func(t *testing.T) {
+		m := newManager()
+
+		var calls []string
+		m.Add(
+			recordingComponent("a", &calls, nil),
+			recordingComponent("b", &calls, errors.New("boom")),
+			recordingComponent("c", &calls, nil),
+		)
+
+		err := m.Run(context.Background())
+
+		assert.ErrorContains(t, err, "failed to start b")
+		assert.Equal(t, []string{"start a", "start b", "stop a"}, calls)
+	})
+
+	t.Run("failure shuts down", func(t *testing.T) {
+		m := newManager()
+		m.Add(lifecycle.Component{
+			Name: "failing",
+			Start: func(ctx context.Context) error {
+				go m.Fail(errors.New("boom"))
+				return nil
+			},
+		})
+
+		err := m.Run(context.Background())
+
+		assert.ErrorContains(t, err, "boom")
+	})
+
+	t.Run("workers are cancelled and waited for", func(t *testing.T) {
+		m := newManager()
+
+		running := make(chan struct{})
+		stopped := false
+		m.Add(lifecycle.Worker(m, "worker", func(ctx context.Context) error {
+			close(running)
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+			stopped = true
+			return ctx.Err()
+		}))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-running
+			cancel()
+		}()
+
+		err := m.Run(ctx)
+
+		assert.Nil(t, err)
+		assert.True(t, stopped)
+	})
+
+	t.Run("server drains in-flight requests", func(t *testing.T) {
+		m := newManager()
+
+		addr := freeAddr(t)
+		received := make(chan struct{})
+		server := &http.Server{
+			Addr: addr,
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(received)
+				time.Sleep(50 * time.Millisecond)
+				w.WriteHeader(http.StatusNoContent)
+			}),
+		}
+		m.Add(lifecycle.Server(m, server))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() {
+			done <- m.Run(ctx)
+		}()
+
+		status := make(chan int)
+		go func() {
+			res, err := retryGet("http://" + addr)
+			if err != nil {
+				status <- 0
+				return
+			}
+			res.Body.Close()
+			status <- res.StatusCode
+		}()
+
+		<-received
+		cancel()
+
+		assert.Equal(t, http.StatusNoContent, <-status)
+		assert.Nil(t, <-done)
+	})
+}
+
+func newManager() *lifecycle.Manager {
+	return lifecycle.NewManager(time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func recordingComponent(name string, calls *[]string, startErr error) lifecycle.Component {
+	return lifecycle.Component{
+		Name: name,
+		Start: func(ctx context.Context) error {
+			*calls = append(*calls, "start "+name)
+			return startErr
+		},
+		Stop: func(ctx context.Context) error {
+			*calls = append(*calls, "stop "+name)
+			return nil
+		},
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+
+	return l.Addr().String()
+}
+
+// retryGet retries until the server has started listening.
+func retryGet(url string) (*http.Response, error) {
+	var err error
+
+	for range 50 {
+		var res *http.Response
+		res, err = http.Get(url)
+		if err == nil {
+			return res, nil
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return nil, err
+}
@@ -0,0 +1,65 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a logger writing JSON, or text when format is "text", to w.
+// Log lines written with a context carry its request metadata.
+func New(w io.Writer, format string, level slog.Level) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler = slog.NewJSONHandler(w, opts)
+	if format == "text" {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	return slog.New(NewContextHandler(handler))
+}
+
+// ContextHandler adds the request and correlation ids, the actor and the
+// trace of the context to every record.
+type ContextHandler struct {
+	slog.Handler
+}
+
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: next}
+}
+
+func (h *ContextHandler) Handle(ctx context.Context, record slog.Record) error {
+	metadata := core.MetadataFromContext(ctx)
+
+	for _, attr := range []slog.Attr{
+		slog.String("requestId", metadata.RequestId),
+		slog.String("correlationId", metadata.CorrelationId),
+		slog.String("causationId", metadata.CausationId),
+		slog.String("actorId", metadata.ActorId),
+	} {
+		if attr.Value.String() != "" {
+			record.AddAttrs(attr)
+		}
+	}
+
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		record.AddAttrs(
+			slog.String("traceId", span.TraceID().String()),
+			slog.String("spanId", span.SpanID().String()),
+		)
+	}
+
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
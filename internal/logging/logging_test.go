@@ -0,0 +1,68 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/markusryoti/survey-ddd/internal/core"
+	"github.com/markusryoti/survey-ddd/internal/logging"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextHandler(t *testing.T) {
+	t.Run("adds the request metadata of the context", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(&buf, "json", slog.LevelInfo).With(slog.String("component", "test"))
+
+		ctx := core.WithMetadata(context.Background(), core.Metadata{
+			RequestId:     "request",
+			CorrelationId: "correlation",
+			ActorId:       "user",
+		})
+		logger.InfoContext(ctx, "hello")
+
+		line := decodeLine(t, &buf)
+		assert.Equal(t, "hello", line["msg"])
+		assert.Equal(t, "test", line["component"])
+		assert.Equal(t, "request", line["requestId"])
+		assert.Equal(t, "correlation", line["correlationId"])
+		assert.Equal(t, "user", line["actorId"])
+		assert.NotContains(t, line, "causationId")
+	})
+
+	t.Run("adds the trace of the context", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(&buf, "json", slog.LevelInfo)
+
+		traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+		spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+		ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceId,
+			SpanID:  spanId,
+		}))
+		logger.InfoContext(ctx, "hello")
+
+		line := decodeLine(t, &buf)
+		assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", line["traceId"])
+		assert.Equal(t, "00f067aa0ba902b7", line["spanId"])
+	})
+
+	t.Run("respects the level", func(t *testing.T) {
+		var buf bytes.Buffer
+		logger := logging.New(&buf, "json", slog.LevelWarn)
+
+		logger.InfoContext(context.Background(), "hello")
+
+		assert.Empty(t, buf.String())
+	})
+}
+
+func decodeLine(t *testing.T, buf *bytes.Buffer) map[string]any {
+	var line map[string]any
+	assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+	return line
+}
@@ -1 +1,47 @@
 package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/markusryoti/survey-ddd/internal/domain/surveys"
+)
+
+// InvitationReader lists invitations without going through the aggregate
+// repository, which can only load a single aggregate by id.
+type InvitationReader interface {
+	ListInvitations(ctx context.Context, surveyId surveys.SurveyId) ([]surveys.Invitation, error)
+}
+
+// ResponseReader lists the responses to a survey.
+type ResponseReader interface {
+	ListResponses(ctx context.Context, surveyId surveys.SurveyId) ([]surveys.SurveyResponse, error)
+}
+
+// IdempotencyRecord is the stored outcome of a request made with an
+// idempotency key.
+type IdempotencyRecord struct {
+	Key         string
+	RequestHash string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+type IdempotencyStore interface {
+	// Reserve claims the key for a request. Records created before
+	// expiredBefore are discarded first. If the key is already in use, the
+	// existing record is returned instead.
+	Reserve(ctx context.Context, key string, requestHash string, expiredBefore time.Time) (*IdempotencyRecord, error)
+	// Complete stores the response of the request that reserved the key.
+	Complete(ctx context.Context, record IdempotencyRecord) error
+	// DeleteExpired removes records created before the given time.
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// SubmissionSagaReader finds submission sagas that haven't finished, so they
+// can be resumed after a restart.
+type SubmissionSagaReader interface {
+	PendingSubmissionSagas(ctx context.Context) ([]surveys.SurveyResponseId, error)
+}